@@ -1,51 +1,114 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
 
 	"github.com/Hashira21/currency-rate/internal/bootstrap"
 	"github.com/Hashira21/currency-rate/internal/controller"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/auth"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/lifecycle"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/metrics"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/ratelimit"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/requestid"
 	"github.com/Hashira21/currency-rate/internal/infrastructure/tech"
-	"github.com/Hashira21/currency-rate/internal/providers/frankfurter"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/tracing"
+	"github.com/Hashira21/currency-rate/internal/models/config"
+	"github.com/Hashira21/currency-rate/internal/repository/cache"
 	"github.com/Hashira21/currency-rate/internal/repository/postgres"
 	"github.com/Hashira21/currency-rate/internal/router"
 	"github.com/Hashira21/currency-rate/internal/service"
+	"github.com/rs/zerolog"
 
 	"github.com/gorilla/handlers"
 )
 
-var (
-	logger = bootstrap.InitLogger()
-	cfg    = bootstrap.InitConfig(logger)
+func main() {
+	logger := bootstrap.InitLogger()
 
-	dbConn = bootstrap.DbConnInit(cfg.Postgres, logger)
+	configPath, args, err := bootstrap.ParseFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, "usage: currency-rate [--config path] [migrate up|down|status]")
+		os.Exit(2)
+	}
 
-	frankfurterPrv = frankfurter.NewProvider(&cfg.FrankfurterClient, logger)
-	validIsoCodes  = bootstrap.GetValidIsoCodes(frankfurterPrv, logger)
-	db             = postgres.New(dbConn, logger)
+	cfg := bootstrap.InitConfig(configPath, logger)
+	if err = bootstrap.SetLogLevel(cfg.Application.LogLevel); err != nil {
+		logger.Fatal().Msg(err.Error())
+	}
 
-	svc = service.New(frankfurterPrv, db, logger)
-	ctr = controller.New(svc, validIsoCodes, logger)
-)
+	// currency-rate migrate up|down|status — управление схемой БД без запуска сервиса
+	if len(args) > 0 && args[0] == "migrate" {
+		os.Exit(migrate(args[1:], cfg, logger))
+	}
 
-func init() {
-	tech.New().SetAppInfo(cfg.Application.Name, cfg.Application.Version)
-	bootstrap.StartSyncRates(cfg.SyncRates, svc, logger)
+	if err = serve(cfg, configPath, logger); err != nil {
+		logger.Error().Msg(err.Error())
+		os.Exit(1)
+	}
 }
 
-func main() {
+func serve(cfg config.Config, configPath string, logger zerolog.Logger) error {
+	shutdownTracing, err := tracing.Init(cfg.Tracing, cfg.Application.Name, cfg.Application.Version)
+	if err != nil {
+		return err
+	}
+
+	dbConn := bootstrap.DbConnInit(cfg.Postgres, logger)
+	bootstrap.RunMigrations(dbConn, logger)
+
+	providers := bootstrap.InitProviders(cfg.Providers, logger)
+	validIsoCodes := bootstrap.GetValidIsoCodes(providers, logger)
+	db := postgres.New(dbConn, cfg.Postgres.Pool.AcquireTimeout, logger)
+
+	// Кэш последних курсов снимает с БД чтение /all-last; очередь и health-проверки работают с БД напрямую
+	var store service.Postgres = db
+	if cfg.Cache.Enabled {
+		store = cache.New(db, cfg.Cache.TTL, logger)
+	}
+
+	svc := service.New(providers, store, cfg.CrossRates, cfg.AutoUpdate, cfg.SyncRates, logger)
+	ctr := controller.New(svc, validIsoCodes, cfg.Application.HttpTimeout, logger)
+
+	tech.New(logger).SetAppInfo(cfg.Application.Name, cfg.Application.Version).
+		AddDependency("postgres", true, dbConn.Ping).
+		AddDependency("rate-providers", false, bootstrap.ProviderCheck(cfg.Health, providers)).
+		AddDependency("rates-queue", false, bootstrap.QueueCheck(cfg.Health, db))
+	if err := metrics.RegisterQueueDepth(db.QueueSize); err != nil {
+		logger.Warn().Msg(fmt.Sprintf("failed to register queue depth metric: %v", err))
+	}
+	bootstrap.SeedRateMetrics(db, logger)
+
+	syncRates := bootstrap.NewSyncRates(cfg.SyncRates, svc, logger)
+	reloader := bootstrap.NewReloader(configPath, cfg, syncRates, providers, svc, logger)
+
+	authn, err := auth.New(cfg.Auth, logger)
+	if err != nil {
+		return err
+	}
+
+	limiter := ratelimit.New(cfg.RateLimit, logger)
+
 	// Создаём роутер
-	r := router.NewRouter(ctr)
+	r := router.NewRouter(ctr, authn, limiter)
+	for _, name := range limiter.Routes() {
+		if r.Get(name) == nil {
+			logger.Warn().Msg(fmt.Sprintf("rate limit configured for unknown route %q", name))
+		}
+	}
 
 	// Добавляем CORS middleware
 	corsHandler := handlers.CORS(
-		handlers.AllowedOrigins([]string{"*"}),                                                // Разрешает запросы с любого домена
+		handlers.AllowedOrigins(cfg.Auth.CORSOrigins),                                         // Только доверенные фронтенды
 		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}), // Разрешённые HTTP-методы
-		handlers.AllowedHeaders([]string{"Content-Type", "Authorization"}),
+		handlers.AllowedHeaders([]string{"Content-Type", "Authorization", auth.APIKeyHeader, requestid.Header}),
+		handlers.ExposedHeaders([]string{requestid.Header, "Retry-After"}),
 	)
 
-	go svc.AutoUpdateRates() // Запускаем горутину для автообновления курсов
 	s := http.Server{
 		Addr:         cfg.Application.Port,
 		Handler:      corsHandler(r), // Оборачиваем роутер в CORS
@@ -53,7 +116,24 @@ func main() {
 		WriteTimeout: cfg.Application.HttpTimeout,
 	}
 
-	logger.Debug().Msg(fmt.Sprintf("server started on port %s", cfg.Application.Port))
+	// Останавливаются в обратном порядке: сначала HTTP-сервер, затем фоновые задачи, БД и экспорт трасс
+	app := lifecycle.New(cfg.Application.ShutdownTimeout, logger).
+		Add("tracing", nil, shutdownTracing).
+		Add("postgres", nil, func(ctx context.Context) error {
+			dbConn.Close()
+			return nil
+		}).
+		Add("auto-update", svc.AutoUpdateRates, nil). // Горутина для автообновления курсов
+		Add("sync-rates", syncRates.Run, nil).
+		Add("config-reload", reloader.Run, nil).
+		Add("http-server", func(ctx context.Context) error {
+			logger.Debug().Msg(fmt.Sprintf("server started on port %s", cfg.Application.Port))
+
+			if err := s.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		}, s.Shutdown)
 
-	logger.Fatal().Msg(s.ListenAndServe().Error())
+	return app.Run(context.Background())
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/bootstrap"
+	"github.com/Hashira21/currency-rate/internal/migrations"
+	"github.com/Hashira21/currency-rate/internal/models/config"
+	"github.com/rs/zerolog"
+)
+
+const migrateUsage = "usage: currency-rate migrate up|down|status"
+
+func migrate(args []string, cfg config.Config, logger zerolog.Logger) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	pool := bootstrap.DbConnInit(cfg.Postgres, logger)
+	defer pool.Close()
+
+	ctx := context.Background()
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		logger.Error().Msg(err.Error())
+		return 1
+	}
+	defer conn.Release()
+
+	migrator := migrations.New(conn.Conn(), logger)
+
+	switch args[0] {
+	case "up":
+		if err := migrator.Up(ctx); err != nil {
+			return 1
+		}
+	case "down":
+		if err := migrator.Down(ctx); err != nil {
+			if errors.Is(err, migrations.ErrNothingToRollback) {
+				logger.Warn().Msg(err.Error())
+				return 0
+			}
+			return 1
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return 1
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		w.Flush()
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	return 0
+}
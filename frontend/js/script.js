@@ -1,4 +1,7 @@
 const API_URL = "http://localhost:8080/api/v1";
+// Учётные данные (API-ключ или JWT) запрашиваются у пользователя при первом отказе в доступе и хранятся
+// только до закрытия вкладки. Для добавления и изменения курсов нужна роль operator, для удаления пары — admin
+const TOKEN_STORAGE_KEY = "currency-rate-token";
 let currentCurrency = "";
 let currentBase = "";
 
@@ -32,9 +35,15 @@ document.addEventListener("DOMContentLoaded", () => {
         }
 
         const rate = await getRateById(id);
-        document.getElementById("searchResult").innerText = rate
-            ? `Курс: ${rate.currency}/${rate.base} = ${rate.rate}`
-            : "Курс не найден";
+        let result = "Курс не найден";
+        if (rate && rate.status === "applied") {
+            result = `Курс: ${rate.currency}/${rate.base} = ${rate.rate}`;
+        } else if (rate && rate.status === "queued") {
+            result = `Курс ${rate.currency}/${rate.base} в очереди, позиция ${rate.queuePosition}`;
+        } else if (rate && rate.status === "failed") {
+            result = `Курс ${rate.currency}/${rate.base} не удалось применить: ${rate.lastError}`;
+        }
+        document.getElementById("searchResult").innerText = result;
     });
 
     document.getElementById("saveEdit").addEventListener("click", async () => {
@@ -48,10 +57,38 @@ document.addEventListener("DOMContentLoaded", () => {
     });
 });
 
+// Заголовки авторизации для запросов к API: JWT передаётся как Bearer, остальное — как API-ключ
+function authHeaders() {
+    const token = sessionStorage.getItem(TOKEN_STORAGE_KEY);
+    if (!token) return {};
+    return token.split(".").length === 3 ? { "Authorization": `Bearer ${token}` } : { "X-API-Key": token };
+}
+
+// Запрос к API с учётными данными; при 401 или 403 запрашивает у пользователя ключ и повторяет запрос один раз
+async function apiFetch(url, options = {}) {
+    const send = () => fetch(url, { ...options, headers: { ...authHeaders(), ...options.headers } });
+
+    const response = await send();
+    if (response.status !== 401 && response.status !== 403) return response;
+
+    const message = response.status === 401
+        ? "Введите API-ключ или токен доступа"
+        : "Недостаточно прав. Введите API-ключ или токен с нужной ролью";
+    const token = prompt(message);
+    if (!token) return response;
+
+    sessionStorage.setItem(TOKEN_STORAGE_KEY, token.trim());
+    return await send();
+}
+
 // Функция для получения данных с API
 async function fetchData(url, options = {}) {
     try {
-        const response = await fetch(url, options);
+        const response = await apiFetch(url, options);
+        if (response.status === 429) {
+            showNotification(`Слишком много запросов, повторите через ${response.headers.get("Retry-After")} с`, true);
+            return;
+        }
         if (!response.ok) throw new Error(`Ошибка: ${response.statusText}`);
         return await response.json();
     } catch (error) {
@@ -71,7 +108,7 @@ async function addRate(currencyPair) {
 }
 
 // Удаление курса по ID
-// Удаление курса по валютной паре
+// Снятие валютной пары с отслеживания: пара архивируется, история курсов сохраняется
 async function deleteRate(currency, base) {
     if (!currency || !base) {
         showNotification("Ошибка: Не указана валютная пара", true);
@@ -79,13 +116,11 @@ async function deleteRate(currency, base) {
     }
     
     try {
-        const response = await fetch(`${API_URL}/delete/${currency}/${base}`, {
-            method: "DELETE",
-        });
+        const response = await apiFetch(`${API_URL}/pairs/${currency}/${base}`, { method: "DELETE" });
 
         if (!response.ok) throw new Error("Ошибка при удалении");
         
-        showNotification(`Курс ${currency}/${base} удалён`);
+        showNotification(`Пара ${currency}/${base} больше не отслеживается`);
         loadRates();
     } catch (error) {
         console.error("Ошибка удаления:", error);
@@ -121,7 +156,7 @@ async function updateRate(currency, base, newRate) {
     }
 
     try {
-        const response = await fetch(`${API_URL}/update?currency=${currency}&base=${base}&rate=${newRate}`, {
+        const response = await apiFetch(`${API_URL}/update?currency=${currency}&base=${base}&rate=${newRate}`, {
             method: "PATCH",
         });
 
@@ -139,7 +174,7 @@ async function updateRate(currency, base, newRate) {
 // Загрузка курсов в таблицу
 async function loadRates() {
     try {
-        const response = await fetch(`${API_URL}/all-last`);
+        const response = await apiFetch(`${API_URL}/all-last`);
         if (!response.ok) {
             throw new Error("Ошибка загрузки данных");
         }
@@ -155,11 +190,13 @@ async function loadRates() {
             } else if (rate.changePct < 0) {
                 arrow = `<span style="color: red; font-size: 16px;">▼ ${rate.changePct.toFixed(2)}%</span>`;
             }
+            // Курсы, установленные вручную, помечаются, чтобы их не путали с котировками провайдера
+            const manual = rate.source === "manual" ? ` <span title="Установлен вручную">✎</span>` : "";
             const row = document.createElement("tr");
             row.innerHTML = `
             <td>${rate.currency}</td>
             <td>${rate.base}</td>
-            <td>${rate.rate} ${arrow}</td>
+            <td title="Источник: ${rate.source}">${rate.rate}${manual} ${arrow}</td>
             <td>${new Date(rate.updateDt).toLocaleString()}</td>
             <td>
                 <button class="icon-button" onclick="openModal('${rate.currency}', '${rate.base}', ${rate.rate})" aria-label="Редактировать">
@@ -225,7 +262,7 @@ function closeChartModal() {
 }
 async function loadChartData(period) {
     try {
-        const response = await fetch(
+        const response = await apiFetch(
             `${API_URL}/history?currency=${currentChartCurrency}&base=${currentChartBase}&period=${period}`
         );
         const data = await response.json();
@@ -246,7 +283,7 @@ async function loadChartData(period) {
                 ),
                 datasets: [{
                     label: `Курс ${currentChartCurrency}/${currentChartBase}`,
-                    data: data.map(item => item.rate),
+                    data: data.map(item => Number(item.rate)),
                     borderColor: '#4361ee',
                     tension: 0.1,
                     pointRadius: 3
@@ -1,18 +1,101 @@
 package bootstrap
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
 	"github.com/BurntSushi/toml"
 	"github.com/Hashira21/currency-rate/internal/models/config"
 	"github.com/rs/zerolog"
 )
 
-const configPath = "./configs/config.toml"
+const (
+	defaultConfigPath = "./configs/config.toml"
+	configPathEnv     = "CONFIG_PATH"
+)
+
+// ParseFlags разбирает общие флаги запуска и возвращает путь к конфигу и оставшиеся аргументы (например, migrate up)
+func ParseFlags(args []string) (string, []string, error) {
+	flags := flag.NewFlagSet("currency-rate", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	configPath := flags.String("config", "", "path to TOML config (overrides "+configPathEnv+")")
+
+	if err := flags.Parse(args); err != nil {
+		return "", nil, err
+	}
+
+	return *configPath, flags.Args(), nil
+}
+
+// InitConfig собирает конфигурацию слоями: значения по умолчанию, затем TOML-файл из флага --config
+// или переменной CONFIG_PATH, затем переменные окружения. При ошибках валидации перечисляет все некорректные поля.
+func InitConfig(flagPath string, logger zerolog.Logger) config.Config {
+	conf, err := LoadConfig(flagPath, os.LookupEnv)
+	if err != nil {
+		logger.Fatal().Msg(err.Error())
+	}
 
-func InitConfig(logger zerolog.Logger) config.Config {
-	var conf config.Config
-	if _, err := toml.DecodeFile(configPath, &conf); err != nil {
-		logger.Fatal().Msg("can`t unmarshall toml configs")
+	for _, warning := range legacyEnvWarnings(os.LookupEnv) {
+		logger.Warn().Msg(warning)
 	}
 
 	return conf
 }
+
+func LoadConfig(flagPath string, lookup func(string) (string, bool)) (config.Config, error) {
+	conf := config.Default()
+	lookup = withLegacyEnv(lookup)
+
+	path, explicit := resolveConfigPath(flagPath, lookup)
+
+	// toml дописывает таблицы [[Providers]] в уже существующие элементы среза, и первый провайдер из файла
+	// унаследовал бы эндпоинты и лимиты провайдера по умолчанию. Провайдеры по умолчанию нужны, только если
+	// файл не задаёт своих.
+	defaultProviders := conf.Providers
+	conf.Providers = nil
+
+	if _, err := toml.DecodeFile(path, &conf); err != nil {
+		// Файл по умолчанию необязателен: в контейнере всё можно задать переменными окружения
+		if explicit || !errors.Is(err, fs.ErrNotExist) {
+			return config.Config{}, fmt.Errorf("can`t unmarshall toml config %s: %w", path, err)
+		}
+	}
+
+	if len(conf.Providers) == 0 {
+		conf.Providers = defaultProviders
+	}
+
+	var errs []config.FieldError
+	errs = append(errs, applyEnv(&conf, lookup)...)
+
+	if err := conf.Validate(); err != nil {
+		var validationErr *config.ValidationError
+		if !errors.As(err, &validationErr) {
+			return config.Config{}, err
+		}
+		errs = append(errs, validationErr.Fields...)
+	}
+
+	if len(errs) > 0 {
+		return config.Config{}, &config.ValidationError{Fields: errs}
+	}
+
+	return conf, nil
+}
+
+// resolveConfigPath возвращает путь к конфигу и признак того, что он задан явно
+func resolveConfigPath(flagPath string, lookup func(string) (string, bool)) (string, bool) {
+	path, explicit := flagPath, flagPath != ""
+	if !explicit {
+		path, explicit = lookup(configPathEnv)
+	}
+	if path == "" {
+		path = defaultConfigPath
+	}
+
+	return path, explicit
+}
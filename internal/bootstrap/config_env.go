@@ -0,0 +1,196 @@
+package bootstrap
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Hashira21/currency-rate/internal/models/config"
+)
+
+// Префиксы переменных окружения для секций, имя которых не совпадает с именем поля
+var envSectionPrefix = map[string]string{
+	"Application": "APP",
+}
+
+// Прежние имена переменных окружения: новое имя -> старое. Старые имена по-прежнему принимаются,
+// но при запуске выводится предупреждение.
+var legacyEnv = map[string]string{
+	"POSTGRES_USER":     "DB_POSTGRES_USER",
+	"POSTGRES_PASSWORD": "DB_POSTGRES_PASSWORD",
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// withLegacyEnv дополняет lookup прежними именами переменных, если новые не заданы
+func withLegacyEnv(lookup func(string) (string, bool)) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		if value, ok := lookup(key); ok {
+			return value, true
+		}
+
+		if legacy, ok := legacyEnv[key]; ok {
+			return lookup(legacy)
+		}
+
+		return "", false
+	}
+}
+
+// legacyEnvWarnings перечисляет заданные переменные со старыми именами
+func legacyEnvWarnings(lookup func(string) (string, bool)) []string {
+	var warnings []string
+
+	for current, legacy := range legacyEnv {
+		if _, ok := lookup(legacy); !ok {
+			continue
+		}
+
+		if _, ok := lookup(current); ok {
+			warnings = append(warnings, fmt.Sprintf("%s is ignored because %s is set, remove it", legacy, current))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("%s is deprecated, rename it to %s", legacy, current))
+		}
+	}
+
+	return warnings
+}
+
+// applyEnv накладывает переменные окружения на конфигурацию. Имя переменной строится из пути к полю:
+// Application.Port -> APP_PORT, Postgres.Pool.MaxConns -> POSTGRES_POOL_MAX_CONNS,
+// для провайдеров префиксом служит имя провайдера: FRANKFURTER_HOST, FRANKFURTER_DEV_GET_RATE_PATH.
+func applyEnv(cfg *config.Config, lookup func(string) (string, bool)) []config.FieldError {
+	var errs []config.FieldError
+
+	root := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		name := root.Type().Field(i).Name
+		if name == "Providers" {
+			continue
+		}
+
+		prefix, ok := envSectionPrefix[name]
+		if !ok {
+			prefix = envName(name)
+		}
+		errs = append(errs, applyEnvStruct(root.Field(i), prefix, lookup)...)
+	}
+
+	for i := range cfg.Providers {
+		prv := &cfg.Providers[i]
+		prefix := envName(prv.Name)
+
+		errs = append(errs, applyEnvStruct(reflect.ValueOf(prv).Elem(), prefix, lookup)...)
+
+		for endpointName, endpoint := range prv.Endpoints {
+			errs = append(errs, applyEnvStruct(reflect.ValueOf(&endpoint).Elem(), prefix+"_"+envName(endpointName), lookup)...)
+			prv.Endpoints[endpointName] = endpoint
+		}
+	}
+
+	return errs
+}
+
+func applyEnvStruct(v reflect.Value, prefix string, lookup func(string) (string, bool)) []config.FieldError {
+	var errs []config.FieldError
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		key := prefix + "_" + envName(v.Type().Field(i).Name)
+
+		if field.Kind() == reflect.Struct {
+			errs = append(errs, applyEnvStruct(field, key, lookup)...)
+			continue
+		}
+
+		raw, ok := lookup(key)
+		if !ok {
+			continue
+		}
+
+		if err := setFromString(field, raw); err != nil {
+			errs = append(errs, config.FieldError{Field: key, Reason: err.Error()})
+		}
+	}
+
+	return errs
+}
+
+func setFromString(field reflect.Value, raw string) error {
+	if field.Type() == durationType {
+		// Как и в TOML, длительность задаётся строкой вида "30s" либо числом наносекунд
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			n, _err := strconv.ParseInt(raw, 10, 64)
+			if _err != nil {
+				return fmt.Errorf("expected duration, got %q", raw)
+			}
+			d = time.Duration(n)
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("expected boolean, got %q", raw)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected integer, got %q", raw)
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("expected number, got %q", raw)
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("field of type %s can't be set from environment", field.Type())
+		}
+		// Списки строк задаются через запятую: AUTH_CORS_ORIGINS=https://a.example,https://b.example
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("field of type %s can't be set from environment", field.Type())
+	}
+
+	return nil
+}
+
+// envName переводит имя поля или провайдера в SCREAMING_SNAKE_CASE: HttpTimeout -> HTTP_TIMEOUT, frankfurter-dev -> FRANKFURTER_DEV
+func envName(name string) string {
+	var out strings.Builder
+
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == '.' || r == ' ':
+			out.WriteRune('_')
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))):
+			out.WriteRune('_')
+			out.WriteRune(r)
+		default:
+			out.WriteRune(unicode.ToUpper(r))
+		}
+	}
+
+	return out.String()
+}
@@ -0,0 +1,208 @@
+package bootstrap
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Hashira21/currency-rate/internal/models/config"
+)
+
+func envLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func TestLoadConfigPostgresCredentials(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantUser     string
+		wantWarnings int
+	}{
+		{
+			name:     "current names",
+			env:      map[string]string{"POSTGRES_USER": "app", "POSTGRES_PASSWORD": "secret"},
+			wantUser: "app",
+		},
+		{
+			name:         "legacy names",
+			env:          map[string]string{"DB_POSTGRES_USER": "legacy", "DB_POSTGRES_PASSWORD": "secret"},
+			wantUser:     "legacy",
+			wantWarnings: 2,
+		},
+		{
+			name: "current names win",
+			env: map[string]string{
+				"POSTGRES_USER": "app", "POSTGRES_PASSWORD": "secret",
+				"DB_POSTGRES_USER": "legacy",
+			},
+			wantUser:     "app",
+			wantWarnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := LoadConfig("", envLookup(tt.env))
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if cfg.Postgres.User != tt.wantUser {
+				t.Errorf("Postgres.User = %q, want %q", cfg.Postgres.User, tt.wantUser)
+			}
+			if warnings := legacyEnvWarnings(envLookup(tt.env)); len(warnings) != tt.wantWarnings {
+				t.Errorf("legacyEnvWarnings() = %v, want %d warnings", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestLoadConfigSyncRatesSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{spec: "@every 15s"},
+		{spec: "*/5 * * * *"},
+		{spec: "@every", wantErr: true},
+		{spec: "every 15s", wantErr: true},
+		{spec: "* * *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := LoadConfig("", envLookup(map[string]string{
+				"POSTGRES_USER":            "app",
+				"POSTGRES_PASSWORD":        "secret",
+				"SYNC_RATES_CONFIG_STRING": tt.spec,
+			}))
+
+			var validationErr *config.ValidationError
+			if tt.wantErr != errors.As(err, &validationErr) {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigJWTSecretLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{name: "not configured"},
+		{name: "32 bytes", secret: "0123456789abcdef0123456789abcdef"},
+		{name: "too short", secret: "changeme", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig("", envLookup(map[string]string{
+				"POSTGRES_USER":     "app",
+				"POSTGRES_PASSWORD": "secret",
+				"AUTH_JWT_SECRET":   tt.secret,
+			}))
+
+			var validationErr *config.ValidationError
+			if tt.wantErr != errors.As(err, &validationErr) {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigProviders(t *testing.T) {
+	tests := []struct {
+		name          string
+		toml          string
+		wantNames     []string
+		wantEndpoints []int
+		wantErr       bool
+	}{
+		{
+			name:          "defaults without providers in file",
+			toml:          "[Application]\nPort = \":8080\"\n",
+			wantNames:     []string{"frankfurter"},
+			wantEndpoints: []int{4},
+		},
+		{
+			name: "single file provider does not inherit defaults",
+			toml: `
+[[Providers]]
+Name = "mirror"
+Type = "frankfurter"
+Host = "https://mirror.example.com"
+`,
+			wantNames:     []string{"mirror"},
+			wantEndpoints: []int{0},
+		},
+		{
+			name: "file providers do not inherit defaults",
+			toml: `
+[[Providers]]
+Name = "mirror"
+Type = "frankfurter"
+Host = "https://mirror.example.com"
+
+[[Providers]]
+Name = "backup"
+Type = "frankfurter"
+Host = "https://backup.example.com"
+[Providers.Endpoints.GetRate]
+Path = "/latest"
+Method = "GET"
+`,
+			wantNames:     []string{"mirror", "backup"},
+			wantEndpoints: []int{0, 1},
+		},
+		{
+			name: "unknown type",
+			toml: `
+[[Providers]]
+Name = "other"
+Type = "unknown"
+Host = "https://other.example.com"
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.toml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadConfig(path, envLookup(map[string]string{
+				"POSTGRES_USER":     "app",
+				"POSTGRES_PASSWORD": "secret",
+			}))
+
+			var validationErr *config.ValidationError
+			if tt.wantErr != errors.As(err, &validationErr) {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(cfg.Providers) != len(tt.wantNames) {
+				t.Fatalf("got %d providers, want %d", len(cfg.Providers), len(tt.wantNames))
+			}
+			for i, prv := range cfg.Providers {
+				if prv.Name != tt.wantNames[i] {
+					t.Errorf("Providers[%d].Name = %q, want %q", i, prv.Name, tt.wantNames[i])
+				}
+				if len(prv.Endpoints) != tt.wantEndpoints[i] {
+					t.Errorf("Providers[%d] has %d endpoints, want %d", i, len(prv.Endpoints), tt.wantEndpoints[i])
+				}
+			}
+		})
+	}
+}
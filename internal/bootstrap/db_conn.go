@@ -3,43 +3,65 @@ package bootstrap
 import (
 	"context"
 	"fmt"
-	"os"
+	"net/url"
 
+	"github.com/Hashira21/currency-rate/internal/infrastructure/tracing"
 	"github.com/Hashira21/currency-rate/internal/models/config"
-	"github.com/jackc/pgx/v5"
+	"github.com/Hashira21/currency-rate/internal/repository/postgres"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 )
 
-func DbConnInit(cfg config.Postgres, logger zerolog.Logger) *pgx.Conn {
-	user := os.Getenv(cfg.User)
-	if user == "" {
-		logger.Fatal().Msg("set env variable for database user")
+func DbConnInit(cfg config.Postgres, logger zerolog.Logger) *pgxpool.Pool {
+	connUrl := url.URL{
+		Scheme: "postgresql",
+		User:   url.UserPassword(cfg.User, cfg.Password),
+		Host:   fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Path:   cfg.Database,
 	}
-
-	password := os.Getenv(cfg.Password)
-	if password == "" {
-		logger.Fatal().Msg("set env variable for database password")
-	}
-
-	connStr := fmt.Sprintf("postgresql://%s:%s@%s:%d/%s", user, password, cfg.Host, cfg.Port, cfg.Database)
-	pgConnConfig, err := pgx.ParseConfig(connStr)
+	poolConfig, err := pgxpool.ParseConfig(connUrl.String())
 	if err != nil {
 		logger.Fatal().Msg(err.Error())
 	}
 
-	conn, err := pgx.ConnectConfig(context.Background(), pgConnConfig)
+	// Нулевые значения оставляют настройки pgxpool по умолчанию
+	if cfg.Pool.MaxConns > 0 {
+		poolConfig.MaxConns = cfg.Pool.MaxConns
+	}
+	if cfg.Pool.MinConns > 0 {
+		poolConfig.MinConns = cfg.Pool.MinConns
+	}
+	if cfg.Pool.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.Pool.MaxConnLifetime
+	}
+	if cfg.Pool.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.Pool.MaxConnIdleTime
+	}
+	if cfg.Pool.HealthCheckPeriod > 0 {
+		poolConfig.HealthCheckPeriod = cfg.Pool.HealthCheckPeriod
+	}
+
+	poolConfig.ConnConfig.Tracer = tracing.NewPgxTracer()
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		logger.Fatal().Msg(err.Error())
 		return nil
 	}
 
-	err = conn.Ping(context.Background())
+	err = pool.Ping(context.Background())
 	if err != nil {
 		logger.Fatal().Msg(err.Error())
 		return nil
 	}
 
-	logger.Debug().Msg("database connected successfully")
+	if err = prometheus.Register(postgres.NewPoolCollector(pool)); err != nil {
+		logger.Warn().Msg(fmt.Sprintf("failed to register pool metrics: %v", err))
+	}
+
+	logger.Debug().Msg(fmt.Sprintf("database connected successfully, pool size %d..%d",
+		poolConfig.MinConns, poolConfig.MaxConns))
 
-	return conn
+	return pool
 }
@@ -0,0 +1,53 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models/config"
+)
+
+const (
+	defaultProviderMaxAge  = 5 * time.Minute
+	defaultQueueMaxBacklog = 1000
+)
+
+type ProviderHealth interface {
+	Healthy(maxAge time.Duration) error
+}
+
+type QueueSizer interface {
+	QueueSize(ctx context.Context) (int64, error)
+}
+
+func ProviderCheck(cfg config.Health, provider ProviderHealth) func(ctx context.Context) error {
+	maxAge := cfg.ProviderMaxAge
+	if maxAge <= 0 {
+		maxAge = defaultProviderMaxAge
+	}
+
+	return func(ctx context.Context) error {
+		return provider.Healthy(maxAge)
+	}
+}
+
+func QueueCheck(cfg config.Health, queue QueueSizer) func(ctx context.Context) error {
+	maxBacklog := cfg.QueueMaxBacklog
+	if maxBacklog <= 0 {
+		maxBacklog = defaultQueueMaxBacklog
+	}
+
+	return func(ctx context.Context) error {
+		size, err := queue.QueueSize(ctx)
+		if err != nil {
+			return err
+		}
+
+		if size > maxBacklog {
+			return fmt.Errorf("queue backlog %d exceeds %d", size, maxBacklog)
+		}
+
+		return nil
+	}
+}
@@ -1,9 +1,12 @@
 package bootstrap
 
 import (
+	"fmt"
 	"os"
 	"time"
 
+	"github.com/Hashira21/currency-rate/internal/infrastructure/requestid"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/tracing"
 	"github.com/rs/zerolog"
 )
 
@@ -13,5 +16,22 @@ func InitLogger() zerolog.Logger {
 		With().
 		Timestamp().
 		Caller().
-		Logger()
+		Logger().
+		Hook(tracing.LogHook{}, requestid.LogHook{}) // trace_id, span_id и request_id для записей с контекстом
+}
+
+// SetLogLevel меняет уровень логирования всех логгеров приложения; пустая строка означает trace,
+// как у логгера из InitLogger
+func SetLogLevel(level string) error {
+	if level == "" {
+		level = zerolog.LevelTraceValue
+	}
+
+	parsed, err := zerolog.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	zerolog.SetGlobalLevel(parsed)
+
+	return nil
 }
@@ -0,0 +1,24 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/Hashira21/currency-rate/internal/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+)
+
+func RunMigrations(pool *pgxpool.Pool, logger zerolog.Logger) {
+	// Миграции держат advisory-блокировку на уровне сессии, поэтому работают на выделенном соединении
+	conn, err := pool.Acquire(context.Background())
+	if err != nil {
+		logger.Fatal().Msg(err.Error())
+	}
+	defer conn.Release()
+
+	if err = migrations.New(conn.Conn(), logger).Up(context.Background()); err != nil {
+		logger.Fatal().Msg(err.Error())
+	}
+
+	logger.Debug().Msg("database schema is up to date")
+}
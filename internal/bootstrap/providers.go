@@ -0,0 +1,31 @@
+package bootstrap
+
+import (
+	"fmt"
+
+	"github.com/Hashira21/currency-rate/internal/models/config"
+	"github.com/Hashira21/currency-rate/internal/providers"
+	"github.com/Hashira21/currency-rate/internal/providers/frankfurter"
+	"github.com/rs/zerolog"
+)
+
+func InitProviders(cfg []config.Provider, logger zerolog.Logger) *providers.Registry {
+	registry := providers.New(logger)
+
+	for _, prvCfg := range cfg {
+		switch prvCfg.Type {
+		case frankfurter.Type:
+			registry.Register(prvCfg.Name, prvCfg.Priority, frankfurter.NewProvider(&prvCfg, logger))
+		default:
+			logger.Fatal().Msg(fmt.Sprintf("unknown type %q of provider %s", prvCfg.Type, prvCfg.Name))
+		}
+
+		logger.Debug().Msg(fmt.Sprintf("provider %s registered with priority %d", prvCfg.Name, prvCfg.Priority))
+	}
+
+	if registry.Len() == 0 {
+		logger.Fatal().Msg(providers.ErrNoProviders.Error())
+	}
+
+	return registry
+}
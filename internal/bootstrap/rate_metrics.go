@@ -0,0 +1,36 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/infrastructure/metrics"
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/rs/zerolog"
+)
+
+const seedRateMetricsTimeout = 5 * time.Second
+
+type LastRatesReader interface {
+	GetAllLastRates(ctx context.Context, excludeManual bool) ([]models.CurrencyRateLast, error)
+}
+
+// SeedRateMetrics заполняет метрики курсов сохранёнными значениями, чтобы после перезапуска
+// алерт на устаревшие курсы работал ещё до первого успешного обновления
+func SeedRateMetrics(db LastRatesReader, logger zerolog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), seedRateMetricsTimeout)
+	defer cancel()
+
+	rates, err := db.GetAllLastRates(ctx, false)
+	if err != nil {
+		logger.Warn().Msg(fmt.Sprintf("failed to seed rate metrics: %v", err))
+		return
+	}
+
+	for _, rate := range rates {
+		metrics.ObserveRate(rate.Currency, rate.Base, rate.Rate, rate.UpdateDt)
+	}
+
+	logger.Debug().Msg(fmt.Sprintf("rate metrics seeded for %d pairs", len(rates)))
+}
@@ -0,0 +1,238 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models/config"
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+)
+
+// Пауза после последнего события файловой системы: редакторы и ConfigMap обновляют файл в несколько шагов
+const reloadDebounce = 500 * time.Millisecond
+
+// ProviderHosts — реестр провайдеров, меняющий адрес API провайдера по имени.
+// Сами провайдеры для этого реализуют providers.HostSetter.
+type ProviderHosts interface {
+	SetHost(name, host string) error
+}
+
+type IntervalSetter interface {
+	SetAutoUpdateInterval(interval time.Duration)
+}
+
+// Reloader перечитывает конфиг при изменении файла и по SIGHUP и применяет изменения, не требующие перезапуска:
+// расписание синхронизации, адреса провайдеров, интервал автообновления и уровень логирования.
+type Reloader struct {
+	flagPath   string
+	current    config.Config
+	syncRates  *SyncRates
+	providers  ProviderHosts
+	autoUpdate IntervalSetter
+	logger     zerolog.Logger
+}
+
+func NewReloader(flagPath string, cfg config.Config, syncRates *SyncRates, providers ProviderHosts,
+	autoUpdate IntervalSetter, logger zerolog.Logger) *Reloader {
+	return &Reloader{
+		flagPath:   flagPath,
+		current:    cfg,
+		syncRates:  syncRates,
+		providers:  providers,
+		autoUpdate: autoUpdate,
+		logger:     logger,
+	}
+}
+
+// Run следит за файлом конфига и SIGHUP до отмены ctx. Если следить за файлом не удалось,
+// конфиг перечитывается только по SIGHUP.
+func (r *Reloader) Run(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	path, _ := resolveConfigPath(r.flagPath, os.LookupEnv)
+
+	// Пока наблюдение за файлом не включено, каналы nil и в select никогда не срабатывают
+	var events <-chan fsnotify.Event
+	var watchErrs <-chan error
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		r.logger.Warn().Msg(fmt.Sprintf("config file watching disabled, reload with SIGHUP only: %v", err))
+	} else {
+		defer watcher.Close()
+
+		// Следим за каталогом, а не за файлом: при атомарной замене файла наблюдение за ним самим теряется
+		if err = watcher.Add(filepath.Dir(path)); err != nil {
+			r.logger.Warn().Msg(fmt.Sprintf("config file watching disabled, reload with SIGHUP only: %v", err))
+		} else {
+			events, watchErrs = watcher.Events, watcher.Errors
+		}
+	}
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			r.logger.Info().Msg("SIGHUP received, reloading config")
+			r.Reload()
+		case event, ok := <-events:
+			if !ok {
+				events, watchErrs = nil, nil
+				r.logger.Warn().Msg("config watcher closed, reload with SIGHUP only")
+				continue
+			}
+			if filepath.Clean(event.Name) == filepath.Clean(path) || filepath.Base(event.Name) == "..data" {
+				debounce.Reset(reloadDebounce)
+			}
+		case <-debounce.C:
+			r.logger.Info().Msg(fmt.Sprintf("config file %s changed, reloading config", path))
+			r.Reload()
+		case err, ok := <-watchErrs:
+			if !ok {
+				events, watchErrs = nil, nil
+				r.logger.Warn().Msg("config watcher closed, reload with SIGHUP only")
+				continue
+			}
+			r.logger.Warn().Msg(fmt.Sprintf("config watcher error: %v", err))
+		}
+	}
+}
+
+// Reload загружает и проверяет конфиг. Невалидный конфиг отклоняется целиком,
+// изменения, требующие перезапуска, игнорируются с предупреждением.
+func (r *Reloader) Reload() {
+	next, err := LoadConfig(r.flagPath, os.LookupEnv)
+	if err != nil {
+		r.logger.Error().Msg(fmt.Sprintf("config reload rejected, keeping current config: %v", err))
+		return
+	}
+
+	if restart := restartRequired(r.current, next); len(restart) > 0 {
+		r.logger.Warn().Msg(fmt.Sprintf("config reload: changes in %v require a restart and were not applied", restart))
+	}
+
+	applied := r.current
+
+	if next.Application.LogLevel != applied.Application.LogLevel {
+		if err = SetLogLevel(next.Application.LogLevel); err != nil {
+			r.logger.Error().Msg(err.Error())
+		} else {
+			applied.Application.LogLevel = next.Application.LogLevel
+			r.logger.Info().Msg(fmt.Sprintf("log level changed to %s", next.Application.LogLevel))
+		}
+	}
+
+	if next.SyncRates.ConfigString != applied.SyncRates.ConfigString {
+		if err = r.syncRates.Reschedule(next.SyncRates.ConfigString); err != nil {
+			r.logger.Error().Msg(err.Error())
+		} else {
+			applied.SyncRates.ConfigString = next.SyncRates.ConfigString
+			r.logger.Info().Msg(fmt.Sprintf("sync rates rescheduled to %q", next.SyncRates.ConfigString))
+		}
+	}
+
+	if next.AutoUpdate.Interval != applied.AutoUpdate.Interval {
+		r.autoUpdate.SetAutoUpdateInterval(next.AutoUpdate.Interval)
+		applied.AutoUpdate.Interval = next.AutoUpdate.Interval
+	}
+
+	// Состав провайдеров не меняется (иначе нужен перезапуск), поэтому их можно сопоставлять по индексу
+	if sameProviders(applied.Providers, next.Providers) {
+		for i, prv := range next.Providers {
+			if prv.Host == applied.Providers[i].Host {
+				continue
+			}
+
+			if err = r.providers.SetHost(prv.Name, prv.Host); err != nil {
+				r.logger.Error().Msg(err.Error())
+				continue
+			}
+			applied.Providers[i].Host = prv.Host
+			r.logger.Info().Msg(fmt.Sprintf("provider %s switched to %s", prv.Name, prv.Host))
+		}
+	}
+
+	r.current = applied
+}
+
+// restartRequired перечисляет секции, изменения в которых нельзя применить без перезапуска
+func restartRequired(current, next config.Config) []string {
+	var fields []string
+
+	if next.Application.Port != current.Application.Port {
+		fields = append(fields, "Application.Port")
+	}
+	if next.Application.HttpTimeout != current.Application.HttpTimeout {
+		fields = append(fields, "Application.HttpTimeout")
+	}
+	if next.Application.ShutdownTimeout != current.Application.ShutdownTimeout {
+		fields = append(fields, "Application.ShutdownTimeout")
+	}
+	syncRates := next.SyncRates
+	syncRates.ConfigString = current.SyncRates.ConfigString
+	if syncRates != current.SyncRates {
+		fields = append(fields, "SyncRates")
+	}
+	if next.AutoUpdate.PollInterval != current.AutoUpdate.PollInterval ||
+		next.AutoUpdate.PairTimeout != current.AutoUpdate.PairTimeout ||
+		next.AutoUpdate.Jitter != current.AutoUpdate.Jitter {
+		fields = append(fields, "AutoUpdate")
+	}
+	if next.Postgres != current.Postgres {
+		fields = append(fields, "Postgres")
+	}
+	if !sameProviders(current.Providers, next.Providers) {
+		fields = append(fields, "Providers")
+	}
+	if next.CrossRates != current.CrossRates {
+		fields = append(fields, "CrossRates")
+	}
+	if next.Health != current.Health {
+		fields = append(fields, "Health")
+	}
+	if next.Tracing != current.Tracing {
+		fields = append(fields, "Tracing")
+	}
+	if !reflect.DeepEqual(next.Auth, current.Auth) {
+		fields = append(fields, "Auth")
+	}
+	if !reflect.DeepEqual(next.RateLimit, current.RateLimit) {
+		fields = append(fields, "RateLimit")
+	}
+	if next.Cache != current.Cache {
+		fields = append(fields, "Cache")
+	}
+
+	return fields
+}
+
+// sameProviders сравнивает провайдеров без учёта адреса API, который меняется на лету
+func sameProviders(current, next []config.Provider) bool {
+	if len(current) != len(next) {
+		return false
+	}
+
+	for i := range current {
+		a, b := current[i], next[i]
+		a.Host, b.Host = "", ""
+		if !reflect.DeepEqual(a, b) {
+			return false
+		}
+	}
+
+	return true
+}
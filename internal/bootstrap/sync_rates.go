@@ -1,20 +1,90 @@
 package bootstrap
 
 import (
+	"context"
+	"fmt"
+	"sync"
+
 	"github.com/Hashira21/currency-rate/internal/models/config"
 	"github.com/robfig/cron/v3"
 	"github.com/rs/zerolog"
 )
 
 type Service interface {
-	SyncRates()
+	SyncRates(ctx context.Context)
 }
 
-func StartSyncRates(cfg config.SyncRates, service Service, logger zerolog.Logger) {
-	cronJob := cron.New()
-	_, err := cronJob.AddFunc(cfg.ConfigString, service.SyncRates)
-	if err != nil {
+// SyncRates — планировщик синхронизации очереди курсов, расписание которого можно сменить на лету
+type SyncRates struct {
+	mu      sync.Mutex
+	cron    *cron.Cron
+	entryId cron.EntryID
+	spec    string
+	service Service
+	logger  zerolog.Logger
+
+	// ctx запущенных задач — контекст компонента, отменяется при остановке
+	ctx context.Context
+}
+
+// NewSyncRates настраивает расписание синхронизации; задачи начинают выполняться после запуска Run
+func NewSyncRates(cfg config.SyncRates, service Service, logger zerolog.Logger) *SyncRates {
+	syncRates := &SyncRates{
+		cron:    cron.New(),
+		service: service,
+		logger:  logger,
+		ctx:     context.Background(),
+	}
+
+	if err := syncRates.Reschedule(cfg.ConfigString); err != nil {
 		logger.Error().Msg(err.Error())
 	}
-	cronJob.Start()
+
+	return syncRates
+}
+
+// Run запускает планировщик до отмены ctx. После отмены новые задачи не запускаются, уже запущенные
+// прерываются через ctx, и Run ждёт их завершения. Прерванная пачка очереди откатывается и будет
+// обработана после перезапуска.
+func (s *SyncRates) Run(ctx context.Context) error {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+
+	s.cron.Start()
+	<-ctx.Done()
+	<-s.cron.Stop().Done()
+
+	return nil
+}
+
+// Reschedule заменяет расписание задачи. При некорректном spec остаётся прежнее расписание.
+func (s *SyncRates) Reschedule(spec string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if spec == s.spec {
+		return nil
+	}
+
+	entryId, err := s.cron.AddFunc(spec, func() { s.service.SyncRates(s.jobCtx()) })
+	if err != nil {
+		return fmt.Errorf("invalid sync rates schedule %q: %w", spec, err)
+	}
+
+	if s.entryId != 0 {
+		s.cron.Remove(s.entryId)
+	}
+	s.entryId, s.spec = entryId, spec
+
+	s.logger.Debug().Msg(fmt.Sprintf("sync rates scheduled %q", spec))
+
+	return nil
+}
+
+func (s *SyncRates) jobCtx() context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ctx
 }
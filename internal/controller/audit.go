@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/infrastructure/response"
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/Hashira21/currency-rate/internal/service"
+)
+
+// GetAuditLog godoc
+// @Summary      Журнал изменений курсов и реестра пар
+// @Description  Записи отдаются новыми первыми. Дата без времени в from означает начало дня, в to — конец дня.
+// @Tags         Admin
+// @Param        rate   query  string  false  "валютная пара" example(EUR/USD)
+// @Param        actor  query  string  false  "кто внёс изменение: имя API-ключа или subject токена"
+// @Param        from   query  string  false  "начало периода" example(2024-01-01)
+// @Param        to     query  string  false  "конец периода" example(2024-01-31T18:00:00Z)
+// @Param        limit  query  int     false  "максимум записей, по умолчанию 100, не больше 1000"
+// @Success      200  {array} models.AuditEntry "success"
+// @Failure      400  "validation error"
+// @Failure      500  "service unavailable"
+// @Router       /audit [get]
+func (ctr *controller) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{Actor: query.Get("actor")}
+
+	if currencyRate := query.Get("rate"); currencyRate != "" {
+		currencies := strings.Split(currencyRate, "/")
+		if len(currencies) != 2 {
+			err_ := errors.New("parameter doesn't match pattern EUR/USD")
+			ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
+			response.WriteError(w, http.StatusBadRequest, err_)
+			return
+		}
+
+		if invalidIso, isInvalid := ctr.validateIsoCode(&currencies[0], &currencies[1]); !isInvalid {
+			err_ := fmt.Errorf("uexpected iso code %s. try this one: %s", invalidIso, ctr.getValidIsoCodesString())
+			ctr.logger.Error().Ctx(r.Context()).Msg(fmt.Sprintf("uexpected iso code %s", invalidIso))
+			response.WriteError(w, http.StatusBadRequest, err_)
+			return
+		}
+
+		filter.Currency, filter.Base = currencies[0], currencies[1]
+	}
+
+	var err error
+	if from := query.Get("from"); from != "" {
+		if filter.From, err = parseRangeBound(from, false); err != nil {
+			err_ := errors.New("parameter from doesn't match pattern 2024-01-01 or 2024-01-01T12:00:00Z")
+			ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
+			response.WriteError(w, http.StatusBadRequest, err_)
+			return
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		if filter.To, err = parseRangeBound(to, true); err != nil {
+			err_ := errors.New("parameter to doesn't match pattern 2024-01-31 or 2024-01-31T18:00:00Z")
+			ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
+			response.WriteError(w, http.StatusBadRequest, err_)
+			return
+		}
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		filter.Limit, err = strconv.Atoi(limitStr)
+		if err != nil || filter.Limit <= 0 {
+			err_ := fmt.Errorf("invalid limit %q", limitStr)
+			ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
+			response.WriteError(w, http.StatusBadRequest, err_)
+			return
+		}
+	}
+
+	entries, err := ctr.service.GetAuditLog(r.Context(), filter)
+	if err != nil {
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+		if errors.Is(err, service.ErrInvalidPeriod) {
+			response.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		response.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respBody, err := json.Marshal(entries)
+	if err != nil {
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+		response.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.Write(w, respBody)
+}
+
+// parseRangeBound принимает момент времени в RFC3339 или дату; дата означает начало дня,
+// а для конца периода — конец дня
+func parseRangeBound(value string, end bool) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+
+	day, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if end {
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+
+	return day, nil
+}
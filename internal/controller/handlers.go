@@ -5,13 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Hashira21/currency-rate/internal/infrastructure/response"
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/Hashira21/currency-rate/internal/providers"
+	"github.com/Hashira21/currency-rate/internal/service"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
 )
 
 // UpdateRate godoc
@@ -21,34 +25,41 @@ import (
 // @Success      	200 {object} models.UpdateResponse "success"
 // @Failure      	400 "validation error"
 // @Failure      	500 "service unavailable"
+// @Failure      	502 "providers returned an error or malformed response"
 // @Router       	/ [put]
 func (ctr *controller) UpdateRate(w http.ResponseWriter, r *http.Request) {
 	currencyRate := r.URL.Query().Get("rate")
 	currencies := strings.Split(currencyRate, "/")
 	if len(currencies) != 2 {
 		err_ := errors.New("parameter doesn't match pattern EUR/USD")
-		ctr.logger.Error().Msg(err_.Error())
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
 		response.WriteError(w, http.StatusBadRequest, err_)
 		return
 	}
 
 	if invalidIso, isInvalid := ctr.validateIsoCode(&currencies[0], &currencies[1]); !isInvalid {
 		err_ := fmt.Errorf("uexpected iso code %s. try this one: %s", invalidIso, ctr.getValidIsoCodesString())
-		ctr.logger.Error().Msg(fmt.Sprintf("uexpected iso code %s", invalidIso))
+		ctr.logger.Error().Ctx(r.Context()).Msg(fmt.Sprintf("uexpected iso code %s", invalidIso))
 		response.WriteError(w, http.StatusBadRequest, err_)
 		return
 	}
 
 	rateId, err := ctr.service.GetRateFromProvider(r.Context(), currencies[0], currencies[1])
 	if err != nil {
-		ctr.logger.Error().Msg(err.Error())
+		if errors.Is(err, providers.ErrAllFailed) {
+			ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+			response.WriteError(w, http.StatusBadGateway, err)
+			return
+		}
+
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
 		response.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	respBody, err := json.Marshal(rateId)
 	if err != nil {
-		ctr.logger.Error().Msg(err.Error())
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
 		response.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -57,11 +68,14 @@ func (ctr *controller) UpdateRate(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetById godoc
-// @Summary      	Get currency rate by id
+// @Summary      	Get status of currency rate update by id
+// @Description  	Status is queued, applied, failed or not_found. With wait the request blocks until the rate leaves the queue.
 // @Tags         	Methods
 // @Param 			id path string false "currency rate update ID" example(ed7f018b-dc91-4940-8d57-4f91cfe5a8bc)
-// @Success      	200 {object} models.CurrencyRateWithDt "success"
+// @Param 			wait query string false "long-poll timeout, up to 30s" example(10s)
+// @Success      	200 {object} models.RateStatus "queued, applied or failed"
 // @Failure      	400 "validation error"
+// @Failure      	404 {object} models.RateStatus "not_found"
 // @Failure      	500 "service unavailable"
 // @Router       	/by-id/{id} [get]
 func (ctr *controller) GetById(w http.ResponseWriter, r *http.Request) {
@@ -69,36 +83,56 @@ func (ctr *controller) GetById(w http.ResponseWriter, r *http.Request) {
 
 	if id == "" {
 		err_ := errors.New("set id value")
-		ctr.logger.Error().Msg(err_.Error())
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
 		response.WriteError(w, http.StatusBadRequest, err_)
 		return
 	}
 
 	if err_ := uuid.Validate(id); err_ != nil {
-		ctr.logger.Error().Msg(err_.Error())
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
 		response.WriteError(w, http.StatusBadRequest, err_)
 		return
 	}
 
-	result, err := ctr.service.GetById(r.Context(), id)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			w.WriteHeader(http.StatusNoContent)
+	var wait time.Duration
+	if waitStr := r.URL.Query().Get("wait"); waitStr != "" {
+		var err error
+		wait, err = time.ParseDuration(waitStr)
+		if err != nil || wait < 0 || wait > MaxStatusWait {
+			err_ := fmt.Errorf("wait must be a duration between 0 and %s, got %q", MaxStatusWait, waitStr)
+			ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
+			response.WriteError(w, http.StatusBadRequest, err_)
 			return
 		}
+	}
 
-		ctr.logger.Error().Msg(err.Error())
+	// Ожидание не укладывается в общий таймаут записи сервера, поэтому дедлайн продлевается только для этого запроса
+	if wait > 0 {
+		deadline := time.Now().Add(wait + ctr.writeTimeout)
+		if err_ := http.NewResponseController(w).SetWriteDeadline(deadline); err_ != nil {
+			ctr.logger.Warn().Ctx(r.Context()).Msg(fmt.Sprintf("failed to extend write deadline for long-poll: %v", err_))
+		}
+	}
+
+	result, err := ctr.service.GetRateStatus(r.Context(), id, wait)
+	if err != nil {
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
 		response.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	respBody, err := json.Marshal(result)
 	if err != nil {
-		ctr.logger.Error().Msg(err.Error())
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
 		response.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if result.Status == models.RateNotFound {
+		response.WriteWithStatus(w, http.StatusNotFound, respBody)
+		return
+	}
+
 	response.Write(w, respBody)
 }
 
@@ -106,6 +140,7 @@ func (ctr *controller) GetById(w http.ResponseWriter, r *http.Request) {
 // @Summary      	Get latest currency rate
 // @Tags         	Methods
 // @Param 			rate query string false "currency rate" example(EUR/USD)
+// @Param 			excludeManual query bool false "не учитывать курсы, установленные вручную"
 // @Success      	200 {object} models.CurrencyRateLast "success"
 // @Failure      	400 "validation error"
 // @Failure      	500 "service unavailable"
@@ -115,33 +150,160 @@ func (ctr *controller) GetLastRate(w http.ResponseWriter, r *http.Request) {
 	currencies := strings.Split(currencyRate, "/")
 	if len(currencies) != 2 {
 		err_ := errors.New("parameter doesn't match pattern EUR/USD")
-		ctr.logger.Error().Msg(err_.Error())
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
 		response.WriteError(w, http.StatusBadRequest, err_)
 		return
 	}
 
 	if invalidIso, isInvalid := ctr.validateIsoCode(&currencies[0], &currencies[1]); !isInvalid {
 		err_ := fmt.Errorf("uexpected iso code %s. try this one: %s", invalidIso, ctr.getValidIsoCodesString())
-		ctr.logger.Error().Msg(fmt.Sprintf("uexpected iso code %s", invalidIso))
+		ctr.logger.Error().Ctx(r.Context()).Msg(fmt.Sprintf("uexpected iso code %s", invalidIso))
+		response.WriteError(w, http.StatusBadRequest, err_)
+		return
+	}
+
+	excludeManual, err := parseFlag(r.URL.Query().Get("excludeManual"))
+	if err != nil {
+		err_ := fmt.Errorf("parameter excludeManual: %w", err)
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
 		response.WriteError(w, http.StatusBadRequest, err_)
 		return
 	}
 
-	result, err := ctr.service.GetLastRate(r.Context(), currencies[0], currencies[1])
+	result, err := ctr.service.GetLastRate(r.Context(), currencies[0], currencies[1], excludeManual)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
 
-		ctr.logger.Error().Msg(err.Error())
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
 		response.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	respBody, err := json.Marshal(result)
 	if err != nil {
-		ctr.logger.Error().Msg(err.Error())
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+		response.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.Write(w, respBody)
+}
+
+// GetRateAt godoc
+// @Summary      	Get currency rate effective at the given date
+// @Tags         	Methods
+// @Param 			rate query string true "currency rate" example(EUR/USD)
+// @Param 			date query string true "date or RFC3339 timestamp" example(2024-03-15)
+// @Success      	200 {object} models.CurrencyRateAt "success"
+// @Success      	204 "no rate for this date"
+// @Failure      	400 "validation error"
+// @Failure      	500 "service unavailable"
+// @Failure      	502 "providers returned an error or malformed response"
+// @Router       	/at [get]
+func (ctr *controller) GetRateAt(w http.ResponseWriter, r *http.Request) {
+	currencyRate := r.URL.Query().Get("rate")
+	currencies := strings.Split(currencyRate, "/")
+	if len(currencies) != 2 {
+		err_ := errors.New("parameter doesn't match pattern EUR/USD")
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
+		response.WriteError(w, http.StatusBadRequest, err_)
+		return
+	}
+
+	if invalidIso, isInvalid := ctr.validateIsoCode(&currencies[0], &currencies[1]); !isInvalid {
+		err_ := fmt.Errorf("uexpected iso code %s. try this one: %s", invalidIso, ctr.getValidIsoCodesString())
+		ctr.logger.Error().Ctx(r.Context()).Msg(fmt.Sprintf("uexpected iso code %s", invalidIso))
+		response.WriteError(w, http.StatusBadRequest, err_)
+		return
+	}
+
+	at, err := parseDate(r.URL.Query().Get("date"))
+	if err != nil {
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+		response.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := ctr.service.GetRateAt(r.Context(), currencies[0], currencies[1], at)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			w.WriteHeader(http.StatusNoContent)
+		case errors.Is(err, service.ErrInvalidPeriod):
+			ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+			response.WriteError(w, http.StatusBadRequest, err)
+		case errors.Is(err, providers.ErrAllFailed):
+			ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+			response.WriteError(w, http.StatusBadGateway, err)
+		default:
+			ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+			response.WriteError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	respBody, err := json.Marshal(result)
+	if err != nil {
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+		response.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.Write(w, respBody)
+}
+
+// Convert godoc
+// @Summary      	Convert amount between currencies by the latest rate
+// @Tags         	Methods
+// @Param 			from query string true "source currency" example(USD)
+// @Param 			to query string true "target currency" example(EUR)
+// @Param 			amount query string true "positive amount in source currency" example(1234.56)
+// @Param 			rounding query string false "rounding mode: half-even, half-up, down" example(half-even)
+// @Success      	200 {object} models.ConversionResponse "success"
+// @Success      	204 "no rate for this pair"
+// @Failure      	400 "validation error"
+// @Failure      	500 "service unavailable"
+// @Router       	/convert [get]
+func (ctr *controller) Convert(w http.ResponseWriter, r *http.Request) {
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+
+	if invalidIso, isInvalid := ctr.validateIsoCode(&from, &to); !isInvalid {
+		err_ := fmt.Errorf("uexpected iso code %s. try this one: %s", invalidIso, ctr.getValidIsoCodesString())
+		ctr.logger.Error().Ctx(r.Context()).Msg(fmt.Sprintf("uexpected iso code %s", invalidIso))
+		response.WriteError(w, http.StatusBadRequest, err_)
+		return
+	}
+
+	amount, err := decimal.NewFromString(r.URL.Query().Get("amount"))
+	if err != nil || !amount.IsPositive() {
+		err_ := errors.New("parameter amount must be a positive decimal number like 1234.56")
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
+		response.WriteError(w, http.StatusBadRequest, err_)
+		return
+	}
+
+	result, err := ctr.service.Convert(r.Context(), from, to, amount, r.URL.Query().Get("rounding"))
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			w.WriteHeader(http.StatusNoContent)
+		case errors.Is(err, service.ErrInvalidRounding):
+			ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+			response.WriteError(w, http.StatusBadRequest, err)
+		default:
+			ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+			response.WriteError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	respBody, err := json.Marshal(result)
+	if err != nil {
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
 		response.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -150,16 +312,24 @@ func (ctr *controller) GetLastRate(w http.ResponseWriter, r *http.Request) {
 }
 
 func (ctr *controller) GetAllLastRates(w http.ResponseWriter, r *http.Request) {
-	result, err := ctr.service.GetAllLastRates(r.Context())
+	excludeManual, err := parseFlag(r.URL.Query().Get("excludeManual"))
+	if err != nil {
+		err_ := fmt.Errorf("parameter excludeManual: %w", err)
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
+		response.WriteError(w, http.StatusBadRequest, err_)
+		return
+	}
+
+	result, err := ctr.service.GetAllLastRates(r.Context(), excludeManual)
 	if err != nil {
-		ctr.logger.Error().Msg(err.Error())
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
 		response.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	respBody, err := json.Marshal(result)
 	if err != nil {
-		ctr.logger.Error().Msg(err.Error())
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
 		response.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -179,7 +349,7 @@ func (ctr *controller) DeleteByPair(w http.ResponseWriter, r *http.Request) {
 
 	err := ctr.service.DeleteByPair(r.Context(), currency, base)
 	if err != nil {
-		ctr.logger.Error().Msg(err.Error())
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
 		http.Error(w, "Ошибка удаления курса", http.StatusInternalServerError)
 		return
 	}
@@ -203,22 +373,22 @@ func (ctr *controller) UpdateCurrencyRate(w http.ResponseWriter, r *http.Request
 
 	if currency == "" || base == "" || rateStr == "" {
 		err_ := errors.New("не указаны параметры валюты, базы или курса")
-		ctr.logger.Error().Msg(err_.Error())
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
 		response.WriteError(w, http.StatusBadRequest, err_)
 		return
 	}
 
-	rate, err := strconv.ParseFloat(rateStr, 64)
-	if err != nil || rate <= 0 {
+	rate, err := decimal.NewFromString(rateStr)
+	if err != nil || !rate.IsPositive() {
 		err_ := errors.New("некорректное значение курса")
-		ctr.logger.Error().Msg(err_.Error())
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
 		response.WriteError(w, http.StatusBadRequest, err_)
 		return
 	}
 
 	err = ctr.service.UpdateRate(r.Context(), currency, base, rate)
 	if err != nil {
-		ctr.logger.Error().Msg(err.Error())
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
 		response.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -233,6 +403,7 @@ func (ctr *controller) UpdateCurrencyRate(w http.ResponseWriter, r *http.Request
 // @Param        currency  query  string  true  "Валюта (например: EUR)"
 // @Param        base      query  string  true  "Базовая валюта (например: USD)"
 // @Param        period    query  string  true  "Период (15m,30m,1h,5h,1d,1w)"
+// @Param        excludeManual  query  bool  false  "Не учитывать курсы, установленные вручную"
 // @Success      200       {array} models.CurrencyRateWithDt
 // @Router       /history  [get]
 func (ctr *controller) GetHistory(w http.ResponseWriter, r *http.Request) {
@@ -246,10 +417,18 @@ func (ctr *controller) GetHistory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	excludeManual, err := parseFlag(r.URL.Query().Get("excludeManual"))
+	if err != nil {
+		err_ := fmt.Errorf("parameter excludeManual: %w", err)
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
+		response.WriteError(w, http.StatusBadRequest, err_)
+		return
+	}
+
 	// Получение данных
-	history, err := ctr.service.GetHistory(r.Context(), currency, base, period)
+	history, err := ctr.service.GetHistory(r.Context(), currency, base, period, excludeManual)
 	if err != nil {
-		ctr.logger.Error().Msg(err.Error())
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
 		response.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -257,9 +436,85 @@ func (ctr *controller) GetHistory(w http.ResponseWriter, r *http.Request) {
 	// Отправка ответа
 	respBody, err := json.Marshal(history)
 	if err != nil {
-		ctr.logger.Error().Msg(err.Error())
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
 		response.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 	response.Write(w, respBody)
 }
+
+// Backfill godoc
+// @Summary      Загрузить историю курса от провайдера
+// @Description  Сохраняет дневные курсы пары за период с их реальными датами, пропуская уже имеющиеся дни
+// @Tags         Admin
+// @Param        rate  query  string  true  "currency rate" example(EUR/USD)
+// @Param        from  query  string  true  "начало периода" example(2024-01-01)
+// @Param        to    query  string  true  "конец периода" example(2024-06-30)
+// @Success      200   {object} models.BackfillResponse "success"
+// @Failure      400   "validation error or period longer than 366 days"
+// @Failure      500   "service unavailable"
+// @Failure      502   "providers returned an error or malformed response"
+// @Router       /backfill [post]
+func (ctr *controller) Backfill(w http.ResponseWriter, r *http.Request) {
+	currencyRate := r.URL.Query().Get("rate")
+	currencies := strings.Split(currencyRate, "/")
+	if len(currencies) != 2 {
+		err_ := errors.New("parameter doesn't match pattern EUR/USD")
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
+		response.WriteError(w, http.StatusBadRequest, err_)
+		return
+	}
+
+	if invalidIso, isInvalid := ctr.validateIsoCode(&currencies[0], &currencies[1]); !isInvalid {
+		err_ := fmt.Errorf("uexpected iso code %s. try this one: %s", invalidIso, ctr.getValidIsoCodesString())
+		ctr.logger.Error().Ctx(r.Context()).Msg(fmt.Sprintf("uexpected iso code %s", invalidIso))
+		response.WriteError(w, http.StatusBadRequest, err_)
+		return
+	}
+
+	from, err := time.Parse(time.DateOnly, r.URL.Query().Get("from"))
+	if err != nil {
+		err_ := errors.New("parameter from doesn't match pattern 2024-01-01")
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
+		response.WriteError(w, http.StatusBadRequest, err_)
+		return
+	}
+
+	to, err := time.Parse(time.DateOnly, r.URL.Query().Get("to"))
+	if err != nil {
+		err_ := errors.New("parameter to doesn't match pattern 2024-06-30")
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
+		response.WriteError(w, http.StatusBadRequest, err_)
+		return
+	}
+
+	if to.Sub(from) > maxBackfillDays*24*time.Hour {
+		err_ := fmt.Errorf("period must not exceed %d days, split it into several requests", maxBackfillDays)
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
+		response.WriteError(w, http.StatusBadRequest, err_)
+		return
+	}
+
+	result, err := ctr.service.Backfill(r.Context(), currencies[0], currencies[1], from, to)
+	if err != nil {
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+		switch {
+		case errors.Is(err, service.ErrInvalidPeriod):
+			response.WriteError(w, http.StatusBadRequest, err)
+		case errors.Is(err, providers.ErrAllFailed):
+			response.WriteError(w, http.StatusBadGateway, err)
+		default:
+			response.WriteError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	respBody, err := json.Marshal(result)
+	if err != nil {
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+		response.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.Write(w, respBody)
+}
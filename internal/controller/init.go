@@ -1,19 +1,29 @@
 package controller
 
 import (
+	"time"
+
 	"github.com/rs/zerolog"
 )
 
+// MaxStatusWait ограничивает long-poll в GetById; на время ожидания GetById продлевает дедлайн записи ответа
+const MaxStatusWait = 30 * time.Second
+
+// maxBackfillDays ограничивает период загрузки истории за один запрос
+const maxBackfillDays = 366
+
 type controller struct {
 	service       Service
 	validIsoCodes map[string]struct{}
+	writeTimeout  time.Duration // таймаут записи HTTP-сервера
 	logger        zerolog.Logger
 }
 
-func New(srv Service, validIsoCodes map[string]struct{}, logger zerolog.Logger) *controller {
+func New(srv Service, validIsoCodes map[string]struct{}, writeTimeout time.Duration, logger zerolog.Logger) *controller {
 	return &controller{
 		service:       srv,
 		validIsoCodes: validIsoCodes,
+		writeTimeout:  writeTimeout,
 		logger:        logger,
 	}
 }
@@ -2,16 +2,27 @@ package controller
 
 import (
 	"context"
+	"time"
 
 	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/shopspring/decimal"
 )
 
 type Service interface {
 	GetRateFromProvider(ctx context.Context, toIso, fromIso string) (models.UpdateResponse, error)
-	GetById(ctx context.Context, id string) (models.CurrencyRateWithDt, error)
-	GetLastRate(ctx context.Context, toIso, fromIso string) (models.CurrencyRateLast, error)
-	GetAllLastRates(ctx context.Context) ([]models.CurrencyRateLast, error)
+	GetRateStatus(ctx context.Context, id string, wait time.Duration) (models.RateStatus, error)
+	GetLastRate(ctx context.Context, toIso, fromIso string, excludeManual bool) (models.CurrencyRateLast, error)
+	GetRateAt(ctx context.Context, toIso, fromIso string, at time.Time) (models.CurrencyRateAt, error)
+	Convert(ctx context.Context, fromIso, toIso string, amount decimal.Decimal, rounding string) (models.ConversionResponse, error)
+	GetAllLastRates(ctx context.Context, excludeManual bool) ([]models.CurrencyRateLast, error)
 	DeleteByPair(ctx context.Context, currency, base string) error
-	UpdateRate(ctx context.Context, currency, base string, rate float64) error
-	GetHistory(ctx context.Context, currency, base, period string) ([]models.CurrencyRateWithDt, error)
+	UpdateRate(ctx context.Context, currency, base string, rate decimal.Decimal) error
+	GetHistory(ctx context.Context, currency, base, period string, excludeManual bool) ([]models.CurrencyRateWithDt, error)
+	Backfill(ctx context.Context, currency, base string, from, to time.Time) (models.BackfillResponse, error)
+	GetPairs(ctx context.Context, status string) ([]models.Pair, error)
+	SavePair(ctx context.Context, pair models.Pair) (models.Pair, error)
+	ArchivePair(ctx context.Context, currency, base string) (models.Pair, error)
+	GetDeadLetters(ctx context.Context, limit int) ([]models.DeadLetter, error)
+	RequeueDeadLetter(ctx context.Context, id string) error
+	GetAuditLog(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
 }
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Hashira21/currency-rate/internal/infrastructure/response"
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/Hashira21/currency-rate/internal/service"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetPairs godoc
+// @Summary      Реестр отслеживаемых пар
+// @Tags         Pairs
+// @Param        status  query  string  false  "фильтр по статусу: active, paused, archived"
+// @Success      200  {array} models.Pair "success"
+// @Failure      400  "validation error"
+// @Failure      500  "service unavailable"
+// @Router       /pairs [get]
+func (ctr *controller) GetPairs(w http.ResponseWriter, r *http.Request) {
+	pairs, err := ctr.service.GetPairs(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPair) {
+			ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+			response.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+		response.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respBody, err := json.Marshal(pairs)
+	if err != nil {
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+		response.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.Write(w, respBody)
+}
+
+// SavePair godoc
+// @Summary      Подписать пару на автообновление или приостановить
+// @Description  status: active (по умолчанию) или paused; intervalSec = 0 — интервал по умолчанию из конфигурации.
+// @Description  Повторный вызов для архивной пары возвращает её в реестр.
+// @Tags         Pairs
+// @Param        pair  body  models.PairRequest  true  "пара"
+// @Success      200  {object} models.Pair "success"
+// @Failure      400  "validation error"
+// @Failure      500  "service unavailable"
+// @Router       /pairs [post]
+func (ctr *controller) SavePair(w http.ResponseWriter, r *http.Request) {
+	var req models.PairRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		err_ := fmt.Errorf("invalid request body: %w", err)
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
+		response.WriteError(w, http.StatusBadRequest, err_)
+		return
+	}
+
+	if invalidIso, isInvalid := ctr.validateIsoCode(&req.Currency, &req.Base); !isInvalid {
+		err_ := fmt.Errorf("uexpected iso code %s. try this one: %s", invalidIso, ctr.getValidIsoCodesString())
+		ctr.logger.Error().Ctx(r.Context()).Msg(fmt.Sprintf("uexpected iso code %s", invalidIso))
+		response.WriteError(w, http.StatusBadRequest, err_)
+		return
+	}
+
+	pair, err := ctr.service.SavePair(r.Context(), models.Pair{
+		Currency:    req.Currency,
+		Base:        req.Base,
+		Status:      req.Status,
+		IntervalSec: req.IntervalSec,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPair) {
+			ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+			response.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+		response.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respBody, err := json.Marshal(pair)
+	if err != nil {
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+		response.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.Write(w, respBody)
+}
+
+// ArchivePair godoc
+// @Summary      Архивировать пару
+// @Description  Пара перестаёт обновляться и пропадает из /all-last, история курсов сохраняется
+// @Tags         Pairs
+// @Param        currency  path  string  true  "валюта" example(EUR)
+// @Param        base      path  string  true  "базовая валюта" example(USD)
+// @Success      200  {object} models.Pair "success"
+// @Failure      400  "validation error"
+// @Failure      404  "pair is not registered"
+// @Failure      500  "service unavailable"
+// @Router       /pairs/{currency}/{base} [delete]
+func (ctr *controller) ArchivePair(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	currency := vars["currency"]
+	base := vars["base"]
+
+	if invalidIso, isInvalid := ctr.validateIsoCode(&currency, &base); !isInvalid {
+		err_ := fmt.Errorf("uexpected iso code %s. try this one: %s", invalidIso, ctr.getValidIsoCodesString())
+		ctr.logger.Error().Ctx(r.Context()).Msg(fmt.Sprintf("uexpected iso code %s", invalidIso))
+		response.WriteError(w, http.StatusBadRequest, err_)
+		return
+	}
+
+	pair, err := ctr.service.ArchivePair(r.Context(), currency, base)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.WriteError(w, http.StatusNotFound, fmt.Errorf("pair %s/%s is not registered", currency, base))
+			return
+		}
+
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+		response.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respBody, err := json.Marshal(pair)
+	if err != nil {
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+		response.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.Write(w, respBody)
+}
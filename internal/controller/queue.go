@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Hashira21/currency-rate/internal/infrastructure/response"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetDeadLetters godoc
+// @Summary      Строки очереди, исчерпавшие попытки применения
+// @Tags         Admin
+// @Param        limit  query  int  false  "максимум записей, по умолчанию 100"
+// @Success      200  {array} models.DeadLetter "success"
+// @Failure      400  "validation error"
+// @Failure      500  "service unavailable"
+// @Router       /admin/dead-letter [get]
+func (ctr *controller) GetDeadLetters(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			err_ := fmt.Errorf("invalid limit %q", limitStr)
+			ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
+			response.WriteError(w, http.StatusBadRequest, err_)
+			return
+		}
+	}
+
+	letters, err := ctr.service.GetDeadLetters(r.Context(), limit)
+	if err != nil {
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+		response.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respBody, err := json.Marshal(letters)
+	if err != nil {
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+		response.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.Write(w, respBody)
+}
+
+// RequeueDeadLetter godoc
+// @Summary      Вернуть строку из dead-letter в очередь
+// @Tags         Admin
+// @Param        id  path  string  true  "currency rate update ID" example(ed7f018b-dc91-4940-8d57-4f91cfe5a8bc)
+// @Success      200  "requeued"
+// @Failure      400  "validation error"
+// @Failure      404  "dead-letter not found"
+// @Failure      500  "service unavailable"
+// @Router       /admin/dead-letter/{id}/requeue [post]
+func (ctr *controller) RequeueDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	if err_ := uuid.Validate(id); err_ != nil {
+		ctr.logger.Error().Ctx(r.Context()).Msg(err_.Error())
+		response.WriteError(w, http.StatusBadRequest, err_)
+		return
+	}
+
+	err := ctr.service.RequeueDeadLetter(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			response.WriteError(w, http.StatusNotFound, fmt.Errorf("dead-letter %s not found", id))
+			return
+		}
+
+		ctr.logger.Error().Ctx(r.Context()).Msg(err.Error())
+		response.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.Write(w, []byte(`{"message": "requeued"}`))
+}
@@ -1,8 +1,12 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const isoCodePattern = "^[A-Z]{3}$"
@@ -32,3 +36,38 @@ func (ctr *controller) getValidIsoCodesString() string {
 	}
 	return res.String()[:len(res.String())-1]
 }
+
+// parseDate принимает дату (2024-03-15) или момент времени в RFC3339.
+// Дата без времени означает конец этого дня (или текущий момент для сегодняшней даты),
+// чтобы учесть все курсы, установленные в течение дня.
+func parseDate(value string) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+
+	day, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, errors.New("parameter date doesn't match pattern 2024-03-15 or 2024-03-15T12:00:00Z")
+	}
+
+	endOfDay := day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	if now := time.Now(); !day.After(now) && endOfDay.After(now) {
+		return now, nil
+	}
+
+	return endOfDay, nil
+}
+
+// parseFlag разбирает необязательный логический параметр запроса; отсутствие параметра означает false
+func parseFlag(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+
+	flag, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("expected true or false, got %q", value)
+	}
+
+	return flag, nil
+}
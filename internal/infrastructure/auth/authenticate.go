@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrInvalidToken    = errors.New("invalid credentials")
+	ErrForbidden       = errors.New("insufficient role")
+)
+
+// authenticate определяет субъект запроса по заголовку X-API-Key или Authorization: Bearer.
+// Запрос без учётных данных получает роль для анонимных; неверные учётные данные — всегда ошибка.
+func (a *Authenticator) authenticate(r *http.Request) (Principal, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return a.authenticateAPIKey(key)
+	}
+
+	if header := r.Header.Get("Authorization"); header != "" {
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found {
+			return Principal{}, fmt.Errorf("%w: expected bearer token", ErrInvalidToken)
+		}
+		return a.authenticateJWT(token)
+	}
+
+	return Principal{Subject: MethodAnonymous, Role: a.anonymousRole, Method: MethodAnonymous}, nil
+}
+
+func (a *Authenticator) authenticateAPIKey(key string) (Principal, error) {
+	sum := sha256.Sum256([]byte(key))
+	hash := hex.EncodeToString(sum[:])
+
+	// Сравниваем с каждым ключом за постоянное время, чтобы не раскрывать совпадение по времени ответа
+	var found *apiKey
+	for knownHash, known := range a.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(knownHash), []byte(hash)) == 1 {
+			found = &known
+		}
+	}
+	if found == nil {
+		return Principal{}, fmt.Errorf("%w: unknown api key", ErrInvalidToken)
+	}
+
+	return Principal{Subject: found.name, Role: found.role, Method: MethodAPIKey}, nil
+}
+
+func (a *Authenticator) authenticateJWT(raw string) (Principal, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if a.issuer != "" {
+		options = append(options, jwt.WithIssuer(a.issuer))
+	}
+	if a.audience != "" {
+		options = append(options, jwt.WithAudience(a.audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, a.jwtKey, options...)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return Principal{}, fmt.Errorf("%w: token has no subject", ErrInvalidToken)
+	}
+
+	return Principal{Subject: subject, Role: a.claimRole(claims), Method: MethodJWT}, nil
+}
+
+// jwtKey подбирает ключ проверки подписи: общий секрет для HS256 или ключ из JWKS по kid для RS256
+func (a *Authenticator) jwtKey(token *jwt.Token) (any, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if a.jwtSecret == nil {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		return a.jwtSecret, nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := token.Header["kid"].(string)
+		key, ok := a.jwtKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+}
+
+// claimRole читает роль из claim-а строкой или списком строк; из списка берётся старшая роль
+func (a *Authenticator) claimRole(claims jwt.MapClaims) Role {
+	switch value := claims[a.roleClaim].(type) {
+	case string:
+		return ParseRole(value)
+	case []any:
+		best := RoleNone
+		for _, item := range value {
+			if name, ok := item.(string); ok && ParseRole(name) > best {
+				best = ParseRole(name)
+			}
+		}
+		return best
+	default:
+		return RoleNone
+	}
+}
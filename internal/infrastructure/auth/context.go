@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"context"
+)
+
+// Principal — тот, от чьего имени выполняется запрос
+type Principal struct {
+	Subject string
+	Role    Role
+	Method  string
+}
+
+// System — субъект фоновых задач (автообновление, разбор очереди); задачи кладут его в контекст явно
+var System = Principal{Subject: "system", Role: RoleAdmin, Method: MethodSystem}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext возвращает субъект запроса. Если субъекта в контексте нет, возвращается аноним без прав,
+// чтобы забытый WithPrincipal не давал доступа
+func FromContext(ctx context.Context) Principal {
+	if principal, ok := ctx.Value(principalKey{}).(Principal); ok {
+		return principal
+	}
+
+	return Principal{Subject: MethodAnonymous, Role: RoleNone, Method: MethodAnonymous}
+}
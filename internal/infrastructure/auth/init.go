@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/Hashira21/currency-rate/internal/models/config"
+	"github.com/rs/zerolog"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+
+	MethodAPIKey    = "api_key"
+	MethodJWT       = "jwt"
+	MethodAnonymous = "anonymous"
+	MethodDisabled  = "disabled"
+	MethodSystem    = "system"
+)
+
+// Authenticator проверяет API-ключи и JWT и решает, хватает ли роли для маршрута
+type Authenticator struct {
+	enabled       bool
+	anonymousRole Role
+
+	apiKeys map[string]apiKey // по SHA-256 ключа в hex
+
+	jwtSecret []byte
+	jwtKeys   map[string]*rsa.PublicKey // по kid из JWKS
+	issuer    string
+	audience  string
+	roleClaim string
+
+	logger zerolog.Logger
+}
+
+type apiKey struct {
+	name string
+	role Role
+}
+
+func New(cfg config.Auth, logger zerolog.Logger) (*Authenticator, error) {
+	a := &Authenticator{
+		enabled:       cfg.Enabled,
+		anonymousRole: ParseRole(cfg.AnonymousRole),
+		apiKeys:       make(map[string]apiKey, len(cfg.APIKeys)),
+		issuer:        cfg.JWT.Issuer,
+		audience:      cfg.JWT.Audience,
+		roleClaim:     cfg.JWT.RoleClaim,
+		logger:        logger,
+	}
+
+	for _, key := range cfg.APIKeys {
+		hash, err := hex.DecodeString(key.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("api key %s: %w", key.Name, err)
+		}
+		a.apiKeys[hex.EncodeToString(hash)] = apiKey{name: key.Name, role: ParseRole(key.Role)}
+	}
+
+	if cfg.JWT.Secret != "" {
+		a.jwtSecret = []byte(cfg.JWT.Secret)
+	}
+
+	if cfg.JWT.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWT.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.jwtKeys = keys
+	}
+
+	return a, nil
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Hashira21/currency-rate/internal/infrastructure/response"
+)
+
+// Require пропускает запрос, только если роль субъекта не ниже required, и кладёт субъект в контекст запроса
+func (a *Authenticator) Require(required Role, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.enabled {
+			principal := Principal{Subject: MethodAnonymous, Role: RoleAdmin, Method: MethodDisabled}
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+			return
+		}
+
+		principal, err := a.authenticate(r)
+		if err != nil {
+			a.logger.Warn().Ctx(r.Context()).Msg(fmt.Sprintf("%s %s: %v", r.Method, r.URL.Path, err))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="currency-rate"`)
+			response.WriteError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		if !principal.Role.Allows(required) {
+			if principal.Method == MethodAnonymous {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="currency-rate"`)
+				response.WriteError(w, http.StatusUnauthorized, ErrUnauthenticated)
+				return
+			}
+
+			err = fmt.Errorf("%w: %s requires %s, %s has %s", ErrForbidden, r.URL.Path, required, principal.Subject, principal.Role)
+			a.logger.Warn().Ctx(r.Context()).Msg(err.Error())
+			response.WriteError(w, http.StatusForbidden, errors.Unwrap(err))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
+)
+
+const (
+	testSecret   = "0123456789abcdef0123456789abcdef"
+	testAudience = "currency-rate"
+	readerKey    = "reader-key"
+)
+
+func newTestAuthenticator(t *testing.T, anonymousRole string) *Authenticator {
+	t.Helper()
+
+	sum := sha256.Sum256([]byte(readerKey))
+	a, err := New(config.Auth{
+		Enabled:       true,
+		AnonymousRole: anonymousRole,
+		APIKeys:       []config.APIKey{{Name: "dashboard", Hash: hex.EncodeToString(sum[:]), Role: "reader"}},
+		JWT:           config.JWT{Secret: testSecret, Audience: testAudience, RoleClaim: "role"},
+	}, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	return a
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(method, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	return token
+}
+
+func tokenClaims(role string, expiresIn time.Duration) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":  "backoffice",
+		"aud":  testAudience,
+		"role": role,
+		"exp":  time.Now().Add(expiresIn).Unix(),
+	}
+}
+
+func TestRequire(t *testing.T) {
+	wrongAudience := tokenClaims("admin", time.Hour)
+	wrongAudience["aud"] = "other-service"
+
+	tests := []struct {
+		name          string
+		anonymousRole string
+		required      Role
+		header        string
+		value         string
+		wantStatus    int
+		wantSubject   string
+	}{
+		{
+			name:       "missing credentials",
+			required:   RoleReader,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "anonymous reader",
+			anonymousRole: "reader",
+			required:      RoleReader,
+			wantStatus:    http.StatusOK,
+			wantSubject:   MethodAnonymous,
+		},
+		{
+			name:          "anonymous below required role",
+			anonymousRole: "reader",
+			required:      RoleOperator,
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:        "api key",
+			required:    RoleReader,
+			header:      APIKeyHeader,
+			value:       readerKey,
+			wantStatus:  http.StatusOK,
+			wantSubject: "dashboard",
+		},
+		{
+			name:       "wrong api key",
+			required:   RoleReader,
+			header:     APIKeyHeader,
+			value:      "guessed-key",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "api key role denied",
+			required:   RoleOperator,
+			header:     APIKeyHeader,
+			value:      readerKey,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:        "jwt",
+			required:    RoleAdmin,
+			header:      "Authorization",
+			value:       "Bearer " + signToken(t, jwt.SigningMethodHS256, tokenClaims("admin", time.Hour)),
+			wantStatus:  http.StatusOK,
+			wantSubject: "backoffice",
+		},
+		{
+			name:       "jwt role denied",
+			required:   RoleAdmin,
+			header:     "Authorization",
+			value:      "Bearer " + signToken(t, jwt.SigningMethodHS256, tokenClaims("operator", time.Hour)),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "expired jwt",
+			required:   RoleReader,
+			header:     "Authorization",
+			value:      "Bearer " + signToken(t, jwt.SigningMethodHS256, tokenClaims("admin", -time.Minute)),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "jwt without expiration",
+			required:   RoleReader,
+			header:     "Authorization",
+			value:      "Bearer " + signToken(t, jwt.SigningMethodHS256, jwt.MapClaims{"sub": "backoffice", "aud": testAudience, "role": "admin"}),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "wrong alg",
+			required:   RoleReader,
+			header:     "Authorization",
+			value:      "Bearer " + signToken(t, jwt.SigningMethodHS512, tokenClaims("admin", time.Hour)),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "wrong audience",
+			required:   RoleReader,
+			header:     "Authorization",
+			value:      "Bearer " + signToken(t, jwt.SigningMethodHS256, wrongAudience),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "wrong secret",
+			required:   RoleReader,
+			header:     "Authorization",
+			value:      "Bearer " + signToken(t, jwt.SigningMethodHS256, tokenClaims("admin", time.Hour))[:20] + "tampered",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "not a bearer token",
+			required:   RoleReader,
+			header:     "Authorization",
+			value:      "Basic dXNlcjpwYXNz",
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAuthenticator(t, tt.anonymousRole)
+
+			var subject string
+			handler := a.Require(tt.required, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				subject = FromContext(r.Context()).Subject
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/last", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if subject != tt.wantSubject {
+				t.Errorf("principal subject = %q, want %q", subject, tt.wantSubject)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 response without WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestFromContextWithoutPrincipal(t *testing.T) {
+	principal := FromContext(context.Background())
+	if principal.Role != RoleNone {
+		t.Errorf("FromContext() role = %s, want %s", principal.Role, RoleNone)
+	}
+
+	if got := FromContext(WithPrincipal(context.Background(), System)); got != System {
+		t.Errorf("FromContext() = %+v, want %+v", got, System)
+	}
+}
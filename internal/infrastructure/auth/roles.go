@@ -0,0 +1,36 @@
+package auth
+
+// Role — уровень доступа; роли упорядочены, старшая включает права младших
+type Role int
+
+const (
+	RoleNone Role = iota
+	RoleReader
+	RoleOperator
+	RoleAdmin
+)
+
+var roleNames = map[Role]string{
+	RoleNone:     "none",
+	RoleReader:   "reader",
+	RoleOperator: "operator",
+	RoleAdmin:    "admin",
+}
+
+func ParseRole(name string) Role {
+	for role, roleName := range roleNames {
+		if roleName == name {
+			return role
+		}
+	}
+
+	return RoleNone
+}
+
+func (r Role) String() string {
+	return roleNames[r]
+}
+
+func (r Role) Allows(required Role) bool {
+	return r >= required
+}
@@ -0,0 +1,36 @@
+package lifecycle
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const defaultShutdownTimeout = 15 * time.Second
+
+// component — часть приложения со своим жизненным циклом.
+// run блокируется до отмены контекста или ошибки, stop освобождает ресурсы в пределах дедлайна.
+// Любая из функций может быть nil.
+type component struct {
+	name string
+	run  func(ctx context.Context) error
+	stop func(ctx context.Context) error
+}
+
+type Manager struct {
+	components      []component
+	shutdownTimeout time.Duration
+	logger          zerolog.Logger
+}
+
+func New(shutdownTimeout time.Duration, logger zerolog.Logger) *Manager {
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+
+	return &Manager{
+		shutdownTimeout: shutdownTimeout,
+		logger:          logger,
+	}
+}
@@ -0,0 +1,102 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/signal"
+	"syscall"
+)
+
+var ErrShutdownTimeout = errors.New("shutdown deadline exceeded")
+
+// running — запущенный компонент: cancel отменяет его контекст, done закрывается после возврата run
+type running struct {
+	component
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Add регистрирует компонент. Компоненты останавливаются по одному в порядке, обратном регистрации,
+// поэтому зависимости (например, БД) нужно добавлять раньше тех, кто ими пользуется.
+func (m *Manager) Add(name string, run, stop func(ctx context.Context) error) *Manager {
+	m.components = append(m.components, component{name: name, run: run, stop: stop})
+	return m
+}
+
+// Run запускает компоненты и ждёт SIGINT/SIGTERM или падения любого из них, после чего останавливает
+// все компоненты в пределах shutdownTimeout. Сигнал только запускает остановку: контексты компонентов
+// производны от ctx и отменяются по одному в shutdown, чтобы фоновые задачи работали, пока HTTP-сервер
+// доотвечает на запросы.
+func (m *Manager) Run(ctx context.Context) error {
+	signalCtx, stopSignals := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	errCh := make(chan error, len(m.components))
+	started := make([]running, 0, len(m.components))
+
+	for _, c := range m.components {
+		componentCtx, cancel := context.WithCancel(ctx)
+		r := running{component: c, cancel: cancel, done: make(chan struct{})}
+		started = append(started, r)
+
+		if c.run == nil {
+			close(r.done)
+			continue
+		}
+
+		go func() {
+			defer close(r.done)
+
+			if err := c.run(componentCtx); err != nil {
+				errCh <- fmt.Errorf("%s: %w", c.name, err)
+			}
+		}()
+
+		m.logger.Debug().Msg(fmt.Sprintf("%s started", c.name))
+	}
+
+	var runErr error
+	select {
+	case <-signalCtx.Done():
+		m.logger.Info().Msg("shutdown signal received")
+	case runErr = <-errCh:
+		m.logger.Error().Msg(fmt.Sprintf("component failed, shutting down: %v", runErr))
+	}
+
+	return errors.Join(runErr, m.shutdown(started))
+}
+
+func (m *Manager) shutdown(started []running) error {
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+
+	for i := len(started) - 1; i >= 0; i-- {
+		r := started[i]
+
+		if r.stop != nil {
+			if err := r.stop(shutdownCtx); err != nil {
+				m.logger.Error().Msg(fmt.Sprintf("failed to stop %s: %v", r.name, err))
+				errs = append(errs, fmt.Errorf("%s: %w", r.name, err))
+			}
+		}
+
+		// Отменяем контекст компонента и ждём, пока он завершит текущую работу
+		r.cancel()
+
+		select {
+		case <-r.done:
+			m.logger.Debug().Msg(fmt.Sprintf("%s stopped", r.name))
+		case <-shutdownCtx.Done():
+			errs = append(errs, fmt.Errorf("%s: %w", r.name, ErrShutdownTimeout))
+		}
+	}
+
+	if len(errs) == 0 {
+		m.logger.Info().Msg("application stopped gracefully")
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,88 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestRunCancelsComponentsInReverseOrder(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	started := make(chan struct{}, 2)
+
+	// worker работает до отмены своего контекста
+	var workerCtx context.Context
+	worker := func(ctx context.Context) error {
+		mu.Lock()
+		workerCtx = ctx
+		mu.Unlock()
+
+		started <- struct{}{}
+		<-ctx.Done()
+		record("worker cancelled")
+		return nil
+	}
+
+	// server отвечает на запросы, пока не вызван stop; на момент остановки worker должен ещё работать
+	serverStopped := make(chan struct{})
+	server := func(ctx context.Context) error {
+		started <- struct{}{}
+		<-serverStopped
+		return nil
+	}
+	stopServer := func(ctx context.Context) error {
+		mu.Lock()
+		cancelled := workerCtx.Err() != nil
+		mu.Unlock()
+
+		if cancelled {
+			t.Error("worker context cancelled before the server stopped")
+		}
+		record("server stopped")
+		close(serverStopped)
+		return nil
+	}
+
+	app := New(time.Second, zerolog.Nop()).
+		Add("worker", worker, nil).
+		Add("server", server, stopServer)
+
+	result := make(chan error, 1)
+	go func() { result <- app.Run(context.Background()) }()
+
+	<-started
+	<-started
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatalf("send SIGTERM: %v", err)
+	}
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after SIGTERM")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	want := []string{"server stopped", "worker cancelled"}
+	if len(events) != len(want) || events[0] != want[0] || events[1] != want[1] {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+var cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "cache_requests_total",
+	Help:      "Number of rate cache lookups by lookup type (last, previous, all_last) and result: hit or miss.",
+}, []string{"lookup", "result"})
+
+func ObserveCache(lookup, result string) {
+	cacheRequests.WithLabelValues(lookup, result).Inc()
+}
@@ -0,0 +1,11 @@
+package metrics
+
+import "time"
+
+// ObserveProviderCall фиксирует длительность обращения к провайдеру и, при ошибке, увеличивает счётчик ошибок
+func ObserveProviderCall(provider, operation string, start time.Time, err error) {
+	providerDuration.WithLabelValues(provider, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		providerErrors.WithLabelValues(provider, operation).Inc()
+	}
+}
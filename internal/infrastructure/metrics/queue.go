@@ -0,0 +1,19 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var queueRows = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "queue_rows_total",
+	Help:      "Number of processed rates queue rows by result: applied, retried or dead_lettered.",
+}, []string{"result"})
+
+// ObserveQueueBatch учитывает итог обработки пачки очереди
+func ObserveQueueBatch(applied, retried, deadLettered int) {
+	queueRows.WithLabelValues("applied").Add(float64(applied))
+	queueRows.WithLabelValues("retried").Add(float64(retried))
+	queueRows.WithLabelValues("dead_lettered").Add(float64(deadLettered))
+}
@@ -0,0 +1,16 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "rate_limited_requests_total",
+	Help:      "Number of API requests rejected with 429 by route.",
+}, []string{"route"})
+
+func ObserveRateLimited(route string) {
+	rateLimited.WithLabelValues(route).Inc()
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shopspring/decimal"
+)
+
+const queueDepthTimeout = 2 * time.Second
+
+type pairState struct {
+	rate    float64
+	updated time.Time
+}
+
+// ratesCollector отдаёт последний курс и возраст последнего успешного обновления по каждой паре.
+// Возраст считается в момент скрейпа, поэтому по нему можно алертить на «застывшие» курсы.
+type ratesCollector struct {
+	mu    sync.RWMutex
+	pairs map[[2]string]pairState
+
+	latestRate *prometheus.Desc
+	lastUpdate *prometheus.Desc
+	updateAge  *prometheus.Desc
+}
+
+var rates = &ratesCollector{
+	pairs: make(map[[2]string]pairState),
+	latestRate: prometheus.NewDesc(namespace+"_rate_latest",
+		"Latest stored rate of the tracked pair.", []string{"currency", "base"}, nil),
+	lastUpdate: prometheus.NewDesc(namespace+"_rate_last_update_timestamp_seconds",
+		"Unix time of the last successful update of the pair.", []string{"currency", "base"}, nil),
+	updateAge: prometheus.NewDesc(namespace+"_rate_update_age_seconds",
+		"Seconds since the last successful update of the pair.", []string{"currency", "base"}, nil),
+}
+
+func init() {
+	prometheus.MustRegister(rates)
+}
+
+// ObserveRate запоминает новый курс пары и время его успешного обновления
+func ObserveRate(currency, base string, rate decimal.Decimal, updated time.Time) {
+	rates.mu.Lock()
+	defer rates.mu.Unlock()
+
+	rates.pairs[[2]string{currency, base}] = pairState{rate: rate.InexactFloat64(), updated: updated}
+}
+
+// ForgetRate убирает пару из метрик, например после удаления её истории
+func ForgetRate(currency, base string) {
+	rates.mu.Lock()
+	defer rates.mu.Unlock()
+
+	delete(rates.pairs, [2]string{currency, base})
+}
+
+func (c *ratesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.latestRate
+	ch <- c.lastUpdate
+	ch <- c.updateAge
+}
+
+func (c *ratesCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for pair, state := range c.pairs {
+		ch <- prometheus.MustNewConstMetric(c.latestRate, prometheus.GaugeValue, state.rate, pair[0], pair[1])
+		ch <- prometheus.MustNewConstMetric(c.lastUpdate, prometheus.GaugeValue, float64(state.updated.Unix()), pair[0], pair[1])
+		ch <- prometheus.MustNewConstMetric(c.updateAge, prometheus.GaugeValue, time.Since(state.updated).Seconds(), pair[0], pair[1])
+	}
+}
+
+// RegisterQueueDepth публикует размер очереди rates_queue, запрашивая его при каждом скрейпе
+func RegisterQueueDepth(queueSize func(ctx context.Context) (int64, error)) error {
+	return prometheus.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rates_queue_depth",
+		Help:      "Number of rates waiting in rates_queue.",
+	}, func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), queueDepthTimeout)
+		defer cancel()
+
+		size, err := queueSize(ctx)
+		if err != nil {
+			return -1
+		}
+
+		return float64(size)
+	}))
+}
@@ -0,0 +1,22 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	RefreshSucceeded = "ok"
+	RefreshFailed    = "error"
+	RefreshSkipped   = "skipped"
+)
+
+var pairRefreshes = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "pair_refreshes_total",
+	Help:      "Number of scheduled pair refreshes by result: ok, error or skipped because the previous one is still running.",
+}, []string{"result"})
+
+func ObservePairRefresh(result string) {
+	pairRefreshes.WithLabelValues(result).Inc()
+}
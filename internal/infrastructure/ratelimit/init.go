@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models/config"
+	"github.com/rs/zerolog"
+	"golang.org/x/time/rate"
+)
+
+// Limiter ограничивает частоту запросов каждого клиента к каждому маршруту отдельным token bucket
+type Limiter struct {
+	enabled           bool
+	trustForwardedFor bool
+	idleTTL           time.Duration
+	defaultLimit      config.Limit
+	routes            map[string]config.Limit
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+
+	logger zerolog.Logger
+}
+
+type bucketKey struct {
+	route  string
+	client string
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func New(cfg config.RateLimit, logger zerolog.Logger) *Limiter {
+	return &Limiter{
+		enabled:           cfg.Enabled,
+		trustForwardedFor: cfg.TrustForwardedFor,
+		idleTTL:           cfg.ClientIdleTTL,
+		defaultLimit:      cfg.Default,
+		routes:            cfg.Routes,
+		buckets:           make(map[bucketKey]*bucket),
+		lastSweep:         time.Now(),
+		logger:            logger,
+	}
+}
+
+// Routes возвращает имена маршрутов, для которых в конфигурации задан свой лимит
+func (l *Limiter) Routes() []string {
+	names := make([]string, 0, len(l.routes))
+	for name := range l.routes {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func (l *Limiter) routeLimit(route string) config.Limit {
+	if limit, ok := l.routes[route]; ok {
+		return limit
+	}
+
+	return l.defaultLimit
+}
+
+// reserve берёт токен из бакета клиента на маршруте. Если токена нет, возвращает время до появления следующего.
+func (l *Limiter) reserve(route, client string, limit config.Limit) (time.Duration, bool) {
+	return l.take(route, client, limit, true)
+}
+
+// check проверяет, есть ли в бакете клиента токен, не забирая его
+func (l *Limiter) check(route, client string, limit config.Limit) (time.Duration, bool) {
+	return l.take(route, client, limit, false)
+}
+
+func (l *Limiter) take(route, client string, limit config.Limit, consume bool) (time.Duration, bool) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	key := bucketKey{route: route, client: client}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(limit.RPS), limit.Burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 || !consume {
+		// Запрос отклоняется или токен только проверялся, поэтому токен возвращается в бакет
+		reservation.CancelAt(now)
+		return delay, delay == 0
+	}
+
+	return 0, true
+}
+
+// sweep удаляет бакеты клиентов, от которых давно не было запросов; проходит по карте не чаще раза в idleTTL
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idleTTL {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= l.idleTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
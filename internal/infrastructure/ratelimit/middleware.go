@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/infrastructure/auth"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/metrics"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/response"
+)
+
+var ErrTooManyRequests = errors.New("rate limit exceeded")
+
+// Limit ограничивает частоту запросов к маршруту route. Клиент определяется по субъекту аутентификации
+// (API-ключ или токен), а для анонимных запросов — по IP-адресу, поэтому Limit ставится после auth.Require.
+func (l *Limiter) Limit(route string, next http.Handler) http.Handler {
+	limit := l.routeLimit(route)
+	if !l.enabled || limit.RPS <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := l.clientKey(r)
+
+		if retryAfter, allowed := l.reserve(route, client, limit); !allowed {
+			l.reject(w, r, route, client, retryAfter)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// LimitUnauthorized ставится перед auth.Require и ограничивает отказы в доступе с одного IP-адреса: пока бакет
+// адреса пуст, запросы отклоняются до проверки учётных данных, а токен списывается только за ответы 401 и 403.
+// Так перебор ключей упирается в лимит маршрута, а клиенты за общим адресом не делят лимит успешных запросов.
+func (l *Limiter) LimitUnauthorized(route string, next http.Handler) http.Handler {
+	limit := l.routeLimit(route)
+	if !l.enabled || limit.RPS <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := "denied:ip:" + l.clientIP(r)
+
+		if retryAfter, allowed := l.check(route, client, limit); !allowed {
+			l.reject(w, r, route, client, retryAfter)
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if rec.status == http.StatusUnauthorized || rec.status == http.StatusForbidden {
+			l.reserve(route, client, limit)
+		}
+	})
+}
+
+func (l *Limiter) reject(w http.ResponseWriter, r *http.Request, route, client string, retryAfter time.Duration) {
+	metrics.ObserveRateLimited(route)
+
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	err := fmt.Errorf("%w for %s, retry in %ds", ErrTooManyRequests, route, seconds)
+	l.logger.Warn().Ctx(r.Context()).Msg(fmt.Sprintf("%s: %v", client, err))
+
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	response.WriteError(w, http.StatusTooManyRequests, err)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap нужен http.ResponseController, чтобы обработчик мог продлить дедлайн записи
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+func (l *Limiter) clientKey(r *http.Request) string {
+	principal := auth.FromContext(r.Context())
+	if principal.Method == auth.MethodAPIKey || principal.Method == auth.MethodJWT {
+		return principal.Method + ":" + principal.Subject
+	}
+
+	return "ip:" + l.clientIP(r)
+}
+
+// clientIP — адрес клиента; за доверенным прокси берётся первый адрес из X-Forwarded-For
+func (l *Limiter) clientIP(r *http.Request) string {
+	if l.trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,167 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/infrastructure/auth"
+	"github.com/Hashira21/currency-rate/internal/models/config"
+	"github.com/rs/zerolog"
+)
+
+func newTestLimiter(limit config.Limit, routes map[string]config.Limit) *Limiter {
+	return New(config.RateLimit{
+		Enabled:       true,
+		ClientIdleTTL: time.Minute,
+		Default:       limit,
+		Routes:        routes,
+	}, zerolog.Nop())
+}
+
+func statusHandler(status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+}
+
+func request(remoteAddr string, principal *auth.Principal) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/last", nil)
+	req.RemoteAddr = remoteAddr
+	if principal != nil {
+		req = req.WithContext(auth.WithPrincipal(context.Background(), *principal))
+	}
+
+	return req
+}
+
+func serve(handler http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestLimitBucket(t *testing.T) {
+	l := newTestLimiter(config.Limit{RPS: 0.5, Burst: 2}, map[string]config.Limit{"Backfill": {RPS: 0.1, Burst: 1}})
+	handler := l.Limit("GetLastRate", statusHandler(http.StatusOK))
+
+	for i := range 2 {
+		if rec := serve(handler, request("10.0.0.1:5000", nil)); rec.Code != http.StatusOK {
+			t.Fatalf("request %d within burst: status %d", i+1, rec.Code)
+		}
+	}
+
+	rec := serve(handler, request("10.0.0.1:5001", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over burst: status %d, want 429", rec.Code)
+	}
+	// Токен появляется раз в 2 секунды
+	if retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After")); err != nil || retryAfter < 1 || retryAfter > 2 {
+		t.Errorf("Retry-After = %q, want 1-2 seconds", rec.Header().Get("Retry-After"))
+	}
+
+	if rec := serve(handler, request("10.0.0.2:5000", nil)); rec.Code != http.StatusOK {
+		t.Errorf("another address shares the bucket: status %d", rec.Code)
+	}
+
+	keyClient := &auth.Principal{Subject: "dashboard", Role: auth.RoleReader, Method: auth.MethodAPIKey}
+	if rec := serve(handler, request("10.0.0.1:5000", keyClient)); rec.Code != http.StatusOK {
+		t.Errorf("api key client limited by its address: status %d", rec.Code)
+	}
+
+	backfill := l.Limit("Backfill", statusHandler(http.StatusOK))
+	serve(backfill, request("10.0.0.3:5000", nil))
+	rec = serve(backfill, request("10.0.0.3:5000", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("route limit not applied: status %d", rec.Code)
+	}
+	if retryAfter, _ := strconv.Atoi(rec.Header().Get("Retry-After")); retryAfter < 9 || retryAfter > 10 {
+		t.Errorf("Retry-After = %q, want about 10 seconds for the route limit", rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestLimitDisabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter *Limiter
+	}{
+		{name: "disabled", limiter: New(config.RateLimit{Default: config.Limit{RPS: 1, Burst: 1}}, zerolog.Nop())},
+		{name: "route without limit", limiter: newTestLimiter(config.Limit{}, nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := tt.limiter.LimitUnauthorized("GetLastRate", tt.limiter.Limit("GetLastRate", statusHandler(http.StatusUnauthorized)))
+
+			for range 10 {
+				if rec := serve(handler, request("10.0.0.1:5000", nil)); rec.Code != http.StatusUnauthorized {
+					t.Fatalf("status %d, want requests passed through", rec.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestLimitUnauthorized(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		wantCode int
+	}{
+		{name: "unauthorized consumes tokens", status: http.StatusUnauthorized, wantCode: http.StatusTooManyRequests},
+		{name: "forbidden consumes tokens", status: http.StatusForbidden, wantCode: http.StatusTooManyRequests},
+		{name: "success does not", status: http.StatusOK, wantCode: http.StatusOK},
+		{name: "client error does not", status: http.StatusBadRequest, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestLimiter(config.Limit{RPS: 0.1, Burst: 2}, nil)
+
+			calls := 0
+			handler := l.LimitUnauthorized("GetLastRate", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(tt.status)
+			}))
+
+			var rec *httptest.ResponseRecorder
+			for range 3 {
+				rec = serve(handler, request("10.0.0.1:5000", nil))
+			}
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("third request: status %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusTooManyRequests {
+				if calls != 2 {
+					t.Errorf("handler called %d times, want rejection before auth", calls)
+				}
+				if rec.Header().Get("Retry-After") == "" {
+					t.Error("429 without Retry-After")
+				}
+
+				if rec := serve(handler, request("10.0.0.2:5000", nil)); rec.Code != tt.status {
+					t.Errorf("another address blocked: status %d", rec.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestSweep(t *testing.T) {
+	l := newTestLimiter(config.Limit{RPS: 1, Burst: 1}, nil)
+	l.reserve("GetLastRate", "ip:10.0.0.1", l.defaultLimit)
+
+	l.sweep(time.Now().Add(30 * time.Second))
+	if len(l.buckets) != 1 {
+		t.Fatalf("bucket removed before idle TTL: %d buckets", len(l.buckets))
+	}
+
+	l.sweep(time.Now().Add(2 * time.Minute))
+	if len(l.buckets) != 0 {
+		t.Errorf("idle bucket kept: %d buckets", len(l.buckets))
+	}
+}
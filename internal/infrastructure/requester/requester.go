@@ -8,7 +8,10 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/Hashira21/currency-rate/internal/infrastructure/tracing"
 	"github.com/Hashira21/currency-rate/internal/models/config"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Requester struct {
@@ -16,6 +19,7 @@ type Requester struct {
 	method      string
 	host        string
 	path        string
+	route       string // путь до подстановки параметров, по нему называется span
 	queryParams string
 }
 
@@ -25,6 +29,7 @@ func New(client *http.Client, provider config.Provider, endpoint string) Request
 		method:      provider.Endpoints[endpoint].Method,
 		host:        provider.Host,
 		path:        provider.Endpoints[endpoint].Path,
+		route:       provider.Endpoints[endpoint].Path,
 		queryParams: "",
 	}
 }
@@ -40,7 +45,38 @@ func (req Requester) SetQueryParameters(params url.Values) Requester {
 	return req
 }
 
-func (req Requester) DoWithoutBody(ctx context.Context) (*http.Response, error) {
+// SetHost возвращает копию запроса с другим адресом API
+func (req Requester) SetHost(host string) Requester {
+	req.host = host
+
+	return req
+}
+
+// SetPathParameters подставляет значения в плейсхолдеры вида {name} в пути эндпоинта
+func (req Requester) SetPathParameters(params map[string]string) Requester {
+	for name, value := range params {
+		req.path = strings.ReplaceAll(req.path, fmt.Sprintf("{%s}", name), url.PathEscape(value))
+	}
+
+	return req
+}
+
+func (req Requester) DoWithoutBody(ctx context.Context) (response *http.Response, err error) {
+	ctx, span := tracing.Start(ctx, fmt.Sprintf("%s %s", req.method, req.route),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.method),
+			attribute.String("server.address", req.host),
+			attribute.String("url.template", req.route),
+		),
+	)
+	defer func() {
+		if response != nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", response.StatusCode))
+		}
+		tracing.End(span, err)
+	}()
+
 	var reqUrl strings.Builder
 
 	reqUrl.WriteString(req.host)
@@ -53,8 +89,9 @@ func (req Requester) DoWithoutBody(ctx context.Context) (*http.Response, error)
 	if err != nil {
 		return nil, err
 	}
+	tracing.InjectHeaders(preparedReq)
 
-	response, err := req.client.Do(preparedReq)
+	response, err = req.client.Do(preparedReq)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,66 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+const Header = "X-Request-Id"
+
+// Идентификаторы длиннее считаются мусором и заменяются своими
+const maxLength = 128
+
+// valid разрешает только символы, безопасные для логов, заголовков ответа и журнала аудита:
+// латиницу, цифры и - _ . : /
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for _, c := range []byte(id) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '/':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+type requestIdKey struct{}
+
+// Middleware берёт идентификатор запроса из заголовка X-Request-Id или генерирует новый, если заголовка нет
+// или он не проходит проверку, кладёт его в контекст и возвращает клиенту в ответе
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !valid(id) {
+			id = uuid.New().String()
+		}
+
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIdKey{}, id)))
+	})
+}
+
+// FromContext возвращает идентификатор запроса или пустую строку для фоновых задач
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+
+// LogHook добавляет request_id в записи zerolog, у которых задан контекст через Ctx(ctx)
+type LogHook struct{}
+
+func (LogHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	if ctx := e.GetCtx(); ctx != nil {
+		if id := FromContext(ctx); id != "" {
+			e.Str("request_id", id)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		wantKept bool
+	}{
+		{name: "uuid", header: "3f1c9a52-7f0e-4c1b-9a51-0c4f3f2f6d7e", wantKept: true},
+		{name: "trace-like", header: "gateway:req_42/retry.1", wantKept: true},
+		{name: "missing"},
+		{name: "too long", header: strings.Repeat("a", maxLength+1)},
+		{name: "newline", header: "abc\ninjected=1"},
+		{name: "spaces", header: "abc def"},
+		{name: "html", header: "<script>"},
+		{name: "non-ascii", header: "запрос-1"},
+		{name: "quote", header: `abc"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromCtx string
+			handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fromCtx = FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header[Header] = []string{tt.header}
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(Header)
+			if got != fromCtx {
+				t.Errorf("response id %q differs from context id %q", got, fromCtx)
+			}
+			if tt.wantKept {
+				if got != tt.header {
+					t.Errorf("id = %q, want client id %q", got, tt.header)
+				}
+				return
+			}
+			if uuid.Validate(got) != nil {
+				t.Errorf("id = %q, want generated uuid", got)
+			}
+		})
+	}
+}
@@ -15,6 +15,13 @@ func Write(w http.ResponseWriter, body []byte) {
 	}
 }
 
+// WriteWithStatus пишет JSON-тело с кодом ответа, отличным от 200
+func WriteWithStatus(w http.ResponseWriter, statusCode int, body []byte) {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(body)
+}
+
 func WriteError(w http.ResponseWriter, statusCode int, err error) {
 	w.WriteHeader(statusCode)
 	w.Header().Add("Content-Type", "application/json")
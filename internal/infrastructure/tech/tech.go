@@ -1,31 +1,79 @@
 package tech
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	StatusReady    = "ready"
+	StatusDegraded = "degraded"
+	StatusNotReady = "not_ready"
+
+	checkTimeout = 3 * time.Second
 )
 
 var (
 	appInfo *app
+	checks  []check
 )
 
-func New() *tech {
+func New(logger zerolog.Logger) *tech {
 	return &tech{
-		app: &app{},
+		app:    &app{},
+		logger: logger,
 	}
 }
 
 func GetInfo(w http.ResponseWriter, r *http.Request) {
-	writeJson(w, appInfo)
+	writeJson(w, http.StatusOK, appInfo)
 }
 
+// GetState оставлен для совместимости со старыми проверками и работает как GetReady
 func GetState(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(`{"status":"healthy"}`))
+	GetReady(w, r)
+}
+
+// GetLive отвечает, что процесс жив; зависимости не проверяются
+func GetLive(w http.ResponseWriter, r *http.Request) {
+	writeJson(w, http.StatusOK, map[string]string{"status": "alive"})
+}
+
+// GetReady проверяет все зарегистрированные зависимости и отвечает 503, если недоступна хотя бы одна критичная
+func GetReady(w http.ResponseWriter, r *http.Request) {
+	result := readiness{Status: StatusReady}
+	if appInfo != nil {
+		result.app = *appInfo
+	}
+	result.Dependencies = runChecks(r.Context())
+
+	statusCode := http.StatusOK
+	for _, dep := range result.Dependencies {
+		if dep.Status == StatusUp {
+			continue
+		}
+
+		if dep.Critical {
+			result.Status = StatusNotReady
+			statusCode = http.StatusServiceUnavailable
+			break
+		}
+
+		result.Status = StatusDegraded
+	}
+
+	writeJson(w, statusCode, result)
 }
 
 func (t *tech) SetAppInfo(name, version string) *tech {
@@ -36,22 +84,55 @@ func (t *tech) SetAppInfo(name, version string) *tech {
 	return t
 }
 
+// AddDependency регистрирует проверку зависимости для /tech/ready и добавляет зависимость в /tech/info.
+// Недоступность критичной зависимости делает сервис неготовым, некритичной — деградировавшим.
+func (t *tech) AddDependency(name string, critical bool, fn func(ctx context.Context) error) *tech {
+	checks = append(checks, check{name: name, critical: critical, fn: fn})
+	t.app.Dependencies = append(t.app.Dependencies, dependency{Name: name, Critical: critical})
+
+	return t
+}
+
+func runChecks(ctx context.Context) []dependency {
+	deps := make([]dependency, len(checks))
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			deps[i] = dependency{Name: c.name, Critical: c.critical, Status: StatusUp}
+			if err := c.fn(checkCtx); err != nil {
+				deps[i].Status, deps[i].Message = StatusDown, err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return deps
+}
+
 func (t *tech) getGuidAndHostname() {
 	t.app.Guid = uuid.New().String()
 
 	host, err := os.Hostname()
 	if err != nil {
-		t.logger.Warn().Msg(fmt.Sprintf("failed to get hostname for %s", t.app.Name))
+		t.logger.Warn().Msg(fmt.Sprintf("failed to get hostname for %s: %v", t.app.Name, err))
 	} else {
 		t.app.Hostname = host
 	}
 }
 
-func writeJson(w http.ResponseWriter, data interface{}) {
+func writeJson(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	if dataBytes, err := json.Marshal(&data); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 	} else {
+		w.WriteHeader(statusCode)
 		w.Write(dataBytes)
 	}
 }
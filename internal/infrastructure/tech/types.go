@@ -1,6 +1,10 @@
 package tech
 
-import "github.com/rs/zerolog"
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+)
 
 type tech struct {
 	app    *app
@@ -8,9 +12,29 @@ type tech struct {
 }
 
 type app struct {
-	Name         string   `json:"name"`
-	Version      string   `json:"version"`
-	Guid         string   `json:"guid"`
-	Hostname     string   `json:"hostname"`
-	Dependencies []string `json:"dependencies,omitempty"`
+	Name         string       `json:"name"`
+	Version      string       `json:"version"`
+	Guid         string       `json:"guid"`
+	Hostname     string       `json:"hostname"`
+	Dependencies []dependency `json:"dependencies,omitempty"`
+}
+
+// dependency — зависимость сервиса. В /tech/info отдаётся без статуса: проверки выполняет только /tech/ready.
+type dependency struct {
+	Name     string `json:"name"`
+	Critical bool   `json:"critical"`
+	Status   string `json:"status,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+type readiness struct {
+	Status string `json:"status"`
+	app
+}
+
+// check — проверка зависимости; nil означает, что зависимость доступна
+type check struct {
+	name     string
+	critical bool
+	fn       func(ctx context.Context) error
 }
@@ -0,0 +1,19 @@
+package tracing
+
+import (
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LogHook добавляет trace_id и span_id в записи zerolog, у которых задан контекст через Ctx(ctx)
+type LogHook struct{}
+
+func (LogHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	spanCtx := trace.SpanContextFromContext(e.GetCtx())
+	if !spanCtx.IsValid() {
+		return
+	}
+
+	e.Str("trace_id", spanCtx.TraceID().String()).
+		Str("span_id", spanCtx.SpanID().String())
+}
@@ -0,0 +1,20 @@
+package tracing
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// End закрывает спан, помечая его ошибкой, если err не nil. Отсутствие строк в БД — обычный исход
+// поиска (ответ 204 или 404), а не сбой, поэтому такие спаны ошибкой не помечаются.
+func End(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
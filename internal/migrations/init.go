@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"embed"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// timeout ограничивает одну миграцию или служебный запрос, а не весь прогон
+	timeout = 30 * time.Second
+
+	// lockTimeout — сколько ждать блокировку, пока другая реплика накатывает свои миграции
+	lockTimeout = 10 * time.Minute
+
+	// lockId — ключ advisory-блокировки, чтобы несколько реплик не накатывали миграции одновременно
+	lockId = 7_216_554_031
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+type Migrator struct {
+	conn   *pgx.Conn
+	logger zerolog.Logger
+}
+
+func New(conn *pgx.Conn, logger zerolog.Logger) *Migrator {
+	return &Migrator{
+		conn:   conn,
+		logger: logger,
+	}
+}
@@ -0,0 +1,287 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrNothingToRollback = errors.New("no applied migrations to roll back")
+
+	fileNameRe = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+)
+
+type migration struct {
+	version int64
+	name    string
+	up      string
+	down    string
+}
+
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Up применяет все ещё не применённые миграции по возрастанию версии, каждую в своей транзакции
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(ctx context.Context) error {
+		migrations, applied, err := m.load(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, mg := range migrations {
+			if _, ok := applied[mg.version]; ok {
+				continue
+			}
+
+			err = m.apply(ctx, mg.up, func(ctx context.Context, tx pgx.Tx) error {
+				_, err := tx.Exec(ctx,
+					`INSERT INTO public.schema_migrations (version, name) VALUES ($1, $2);`,
+					mg.version, mg.name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", mg.version, mg.name, err)
+			}
+
+			m.logger.Info().Msg(fmt.Sprintf("migration %04d_%s applied", mg.version, mg.name))
+		}
+
+		return nil
+	})
+}
+
+// Down откатывает последнюю применённую миграцию
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(ctx context.Context) error {
+		migrations, applied, err := m.load(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0; i-- {
+			mg := migrations[i]
+			if _, ok := applied[mg.version]; !ok {
+				continue
+			}
+
+			err = m.apply(ctx, mg.down, func(ctx context.Context, tx pgx.Tx) error {
+				_, err := tx.Exec(ctx,
+					`DELETE FROM public.schema_migrations WHERE version = $1;`,
+					mg.version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("rollback %04d_%s: %w", mg.version, mg.name, err)
+			}
+
+			m.logger.Info().Msg(fmt.Sprintf("migration %04d_%s rolled back", mg.version, mg.name))
+			return nil
+		}
+
+		return ErrNothingToRollback
+	})
+}
+
+// Status возвращает все известные миграции с датой применения; у неприменённых она пустая
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+
+	err := m.withLock(ctx, func(ctx context.Context) error {
+		migrations, applied, err := m.load(ctx)
+		if err != nil {
+			return err
+		}
+
+		statuses = make([]Status, 0, len(migrations))
+		for _, mg := range migrations {
+			status := Status{Version: mg.version, Name: mg.name}
+			if appliedAt, ok := applied[mg.version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+// withLock выполняет fn под advisory-блокировкой. Ожидание блокировки ограничено lockTimeout,
+// а таймауты запросов внутри fn задаются по каждому запросу отдельно.
+func (m *Migrator) withLock(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := m.lock(ctx); err != nil {
+		m.logger.Error().Msg(err.Error())
+		return err
+	}
+	defer m.conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1);`, lockId)
+
+	childCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	_, err := m.conn.Exec(childCtx, `
+        CREATE TABLE IF NOT EXISTS public.schema_migrations (
+            version bigint PRIMARY KEY,
+            name text NOT NULL,
+            applied_at timestamp with time zone NOT NULL DEFAULT now()
+        );
+    `)
+	if err != nil {
+		m.logger.Error().Msg(err.Error())
+		return err
+	}
+
+	if err = fn(ctx); err != nil {
+		m.logger.Error().Msg(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (m *Migrator) lock(ctx context.Context) error {
+	tryCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var locked bool
+	if err := m.conn.QueryRow(tryCtx, `SELECT pg_try_advisory_lock($1);`, lockId).Scan(&locked); err != nil {
+		return err
+	}
+	if locked {
+		return nil
+	}
+
+	m.logger.Info().Msg("waiting for the migration lock held by another instance")
+
+	lockCtx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+
+	if _, err := m.conn.Exec(lockCtx, `SELECT pg_advisory_lock($1);`, lockId); err != nil {
+		return fmt.Errorf("wait for migration lock: %w", err)
+	}
+
+	return nil
+}
+
+// apply выполняет скрипт миграции и запись о ней в одной транзакции с таймаутом на эту миграцию
+func (m *Migrator) apply(ctx context.Context, script string, record func(ctx context.Context, tx pgx.Tx) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tx, err := m.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, script); err != nil {
+		return err
+	}
+
+	if err = record(ctx, tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// load читает встроенные миграции и версии, уже применённые к базе
+func (m *Migrator) load(ctx context.Context) ([]migration, map[int64]time.Time, error) {
+	migrations, err := parseFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := m.conn.Query(ctx, `SELECT version, applied_at FROM public.schema_migrations;`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return migrations, applied, nil
+}
+
+func parseFiles() ([]migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*migration)
+	for _, entry := range entries {
+		parts := fileNameRe.FindStringSubmatch(entry.Name())
+		if parts == nil {
+			return nil, fmt.Errorf("unexpected migration file name %s", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := fs.ReadFile(files, "sql/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mg, ok := byVersion[version]
+		if !ok {
+			mg = &migration{version: version, name: parts[2]}
+			byVersion[version] = mg
+		}
+
+		if mg.name != parts[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, mg.name, parts[2])
+		}
+
+		if parts[3] == "up" {
+			mg.up = string(body)
+		} else {
+			mg.down = string(body)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		if mg.up == "" || mg.down == "" {
+			return nil, fmt.Errorf("migration %04d_%s must have both up and down scripts", mg.version, mg.name)
+		}
+		migrations = append(migrations, *mg)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return migrations, nil
+}
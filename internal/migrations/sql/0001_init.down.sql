@@ -0,0 +1 @@
+DROP SCHEMA IF EXISTS plata_currency_rates CASCADE;
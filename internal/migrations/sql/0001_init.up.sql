@@ -0,0 +1,118 @@
+-- Исходная схема сервиса (ранее разворачивалась из backup.sql).
+-- Все объекты создаются идемпотентно, чтобы миграцию можно было применить к уже развёрнутой базе.
+
+CREATE SCHEMA IF NOT EXISTS plata_currency_rates;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_type t
+        JOIN pg_namespace n ON n.oid = t.typnamespace
+        WHERE n.nspname = 'plata_currency_rates' AND t.typname = 'rate'
+    ) THEN
+        CREATE TYPE plata_currency_rates.rate AS (
+            id uuid,
+            currency character(3),
+            base character(3),
+            rate numeric
+        );
+    END IF;
+END;
+$$;
+
+CREATE TABLE IF NOT EXISTS plata_currency_rates.rates (
+    id uuid NOT NULL,
+    currency character(3) NOT NULL,
+    base character(3) NOT NULL,
+    rate numeric NOT NULL,
+    date timestamp without time zone NOT NULL,
+    CONSTRAINT firstkey PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS plata_currency_rates.rates_queue (
+    id uuid NOT NULL,
+    currency character(3) NOT NULL,
+    base character(3) NOT NULL,
+    rate numeric NOT NULL,
+    date timestamp without time zone NOT NULL,
+    CONSTRAINT rates_queue_pkey PRIMARY KEY (id)
+);
+
+CREATE OR REPLACE FUNCTION plata_currency_rates.add_to_queue(_id uuid, _currency character, _base character, _rate numeric) RETURNS void
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    INSERT INTO plata_currency_rates.rates_queue(id, currency, base, rate, date)
+    VALUES (_id, _currency, _base, _rate, current_timestamp);
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION plata_currency_rates.get_previous_rate(_currency character, _base character)
+    RETURNS TABLE(ret_currency character, ret_base character, ret_rate numeric, ret_date timestamp without time zone)
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    RETURN QUERY
+    SELECT currency, base, rate, date
+    FROM plata_currency_rates.rates
+    WHERE currency = _currency AND base = _base
+    ORDER BY date DESC OFFSET 1 LIMIT 1;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION plata_currency_rates.add_to_rates(_id uuid, _currency character, _base character, _rate numeric)
+    RETURNS TABLE(id uuid, currency character, base character, rate numeric, date timestamp without time zone)
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+    inserted_row plata_currency_rates.rates%ROWTYPE;
+BEGIN
+    INSERT INTO plata_currency_rates.rates(id, currency, base, rate, date)
+    VALUES (_id, _currency, _base, _rate, current_timestamp)
+    RETURNING * INTO inserted_row;
+
+    RETURN QUERY SELECT inserted_row.*;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION plata_currency_rates.confirm_queue()
+    RETURNS TABLE(ret_id uuid, ret_currency character, ret_base character, ret_rate numeric)
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+    deleted_row plata_currency_rates.rates_queue%ROWTYPE;
+BEGIN
+    DELETE FROM plata_currency_rates.rates_queue
+    WHERE id = (SELECT id FROM plata_currency_rates.rates_queue ORDER BY date ASC LIMIT 1)
+    RETURNING * INTO deleted_row;
+
+    IF NOT FOUND THEN
+        RAISE NOTICE 'No records found in rates_queue';
+        RETURN;
+    END IF;
+
+    RETURN QUERY SELECT deleted_row.id, deleted_row.currency, deleted_row.base, deleted_row.rate;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION plata_currency_rates.get_by_id(_id uuid)
+    RETURNS TABLE(ret_id uuid, ret_currency character, ret_base character, ret_rate numeric, ret_date timestamp without time zone)
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    RETURN QUERY SELECT id, currency, base, rate, date
+                 FROM plata_currency_rates.rates
+                 WHERE id = _id;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION plata_currency_rates.get_last_rate(_currency character, _base character)
+    RETURNS TABLE(ret_currency character, ret_base character, ret_rate numeric, ret_date timestamp without time zone)
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    RETURN QUERY SELECT currency, base, rate, date
+                 FROM plata_currency_rates.rates
+                 WHERE currency = _currency and base = _base ORDER BY date DESC LIMIT 1;
+END;
+$$;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS plata_currency_rates.rates_currency_base_date_idx;
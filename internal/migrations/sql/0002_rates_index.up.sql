@@ -0,0 +1,6 @@
+-- Убираем случайно попавшую в public копию add_to_rates с параметром real
+DROP FUNCTION IF EXISTS public.add_to_rates(uuid, character, character, real);
+
+-- Поиск последних и исторических курсов пары идёт по (currency, base) с сортировкой по date
+CREATE INDEX IF NOT EXISTS rates_currency_base_date_idx
+    ON plata_currency_rates.rates (currency, base, date);
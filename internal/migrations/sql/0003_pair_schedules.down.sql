@@ -0,0 +1 @@
+DROP TABLE IF EXISTS plata_currency_rates.pair_schedules;
@@ -0,0 +1,14 @@
+-- Расписание автообновления пар: свой интервал (NULL — интервал по умолчанию из конфигурации) и флаг активности.
+CREATE TABLE IF NOT EXISTS plata_currency_rates.pair_schedules (
+    currency character(3) NOT NULL,
+    base character(3) NOT NULL,
+    interval_sec integer CHECK (interval_sec > 0),
+    active boolean NOT NULL DEFAULT true,
+    update_dt timestamp without time zone NOT NULL DEFAULT current_timestamp,
+    CONSTRAINT pair_schedules_pkey PRIMARY KEY (currency, base)
+);
+
+-- Раньше обновлялись все пары из rates, сохраняем это поведение для уже отслеживаемых пар
+INSERT INTO plata_currency_rates.pair_schedules (currency, base)
+SELECT DISTINCT currency, base FROM plata_currency_rates.rates
+ON CONFLICT DO NOTHING;
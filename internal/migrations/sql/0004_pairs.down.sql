@@ -0,0 +1,9 @@
+ALTER TABLE plata_currency_rates.pairs ADD COLUMN active boolean NOT NULL DEFAULT true;
+
+-- В расписании нет архива: архивные пары, как и приостановленные, просто не обновляются, история курсов сохраняется
+UPDATE plata_currency_rates.pairs SET active = false WHERE status IN ('paused', 'archived');
+
+ALTER TABLE plata_currency_rates.pairs DROP COLUMN status, DROP COLUMN created_dt;
+
+ALTER TABLE plata_currency_rates.pairs RENAME CONSTRAINT pairs_pkey TO pair_schedules_pkey;
+ALTER TABLE plata_currency_rates.pairs RENAME TO pair_schedules;
@@ -0,0 +1,12 @@
+-- Реестр отслеживаемых пар вместо расписания: пару можно приостановить или архивировать, не удаляя историю курсов.
+ALTER TABLE plata_currency_rates.pair_schedules RENAME TO pairs;
+ALTER TABLE plata_currency_rates.pairs RENAME CONSTRAINT pair_schedules_pkey TO pairs_pkey;
+
+ALTER TABLE plata_currency_rates.pairs
+    ADD COLUMN status text NOT NULL DEFAULT 'active'
+        CONSTRAINT pairs_status_check CHECK (status IN ('active', 'paused', 'archived')),
+    ADD COLUMN created_dt timestamp without time zone NOT NULL DEFAULT current_timestamp;
+
+UPDATE plata_currency_rates.pairs SET status = 'paused' WHERE NOT active;
+
+ALTER TABLE plata_currency_rates.pairs DROP COLUMN active;
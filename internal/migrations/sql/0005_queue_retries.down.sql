@@ -0,0 +1,32 @@
+CREATE OR REPLACE FUNCTION plata_currency_rates.confirm_queue()
+    RETURNS TABLE(ret_id uuid, ret_currency character, ret_base character, ret_rate numeric)
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+    deleted_row plata_currency_rates.rates_queue%ROWTYPE;
+BEGIN
+    DELETE FROM plata_currency_rates.rates_queue
+    WHERE id = (SELECT id FROM plata_currency_rates.rates_queue ORDER BY date ASC LIMIT 1)
+    RETURNING * INTO deleted_row;
+
+    IF NOT FOUND THEN
+        RAISE NOTICE 'No records found in rates_queue';
+        RETURN;
+    END IF;
+
+    RETURN QUERY SELECT deleted_row.id, deleted_row.currency, deleted_row.base, deleted_row.rate;
+END;
+$$;
+
+-- Строки из dead-letter возвращаются в очередь, чтобы не потерять их при откате
+INSERT INTO plata_currency_rates.rates_queue (id, currency, base, rate, date)
+SELECT id, currency, base, rate, date FROM plata_currency_rates.rates_dead_letter
+ON CONFLICT DO NOTHING;
+
+DROP TABLE IF EXISTS plata_currency_rates.rates_dead_letter;
+DROP INDEX IF EXISTS plata_currency_rates.rates_queue_next_attempt_idx;
+
+ALTER TABLE plata_currency_rates.rates_queue
+    DROP COLUMN attempts,
+    DROP COLUMN next_attempt_dt,
+    DROP COLUMN last_error;
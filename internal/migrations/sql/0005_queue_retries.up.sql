@@ -0,0 +1,24 @@
+-- Очередь обрабатывается пачками несколькими репликами: неудачные строки повторяются с задержкой,
+-- а исчерпавшие попытки переносятся в rates_dead_letter.
+ALTER TABLE plata_currency_rates.rates_queue
+    ADD COLUMN attempts integer NOT NULL DEFAULT 0,
+    ADD COLUMN next_attempt_dt timestamp without time zone NOT NULL DEFAULT current_timestamp,
+    ADD COLUMN last_error text;
+
+CREATE INDEX IF NOT EXISTS rates_queue_next_attempt_idx
+    ON plata_currency_rates.rates_queue (next_attempt_dt, date);
+
+CREATE TABLE IF NOT EXISTS plata_currency_rates.rates_dead_letter (
+    id uuid NOT NULL,
+    currency character(3) NOT NULL,
+    base character(3) NOT NULL,
+    rate numeric NOT NULL,
+    date timestamp without time zone NOT NULL,
+    attempts integer NOT NULL,
+    last_error text NOT NULL,
+    failed_dt timestamp without time zone NOT NULL DEFAULT current_timestamp,
+    CONSTRAINT rates_dead_letter_pkey PRIMARY KEY (id)
+);
+
+-- Заменена пакетной обработкой в приложении
+DROP FUNCTION IF EXISTS plata_currency_rates.confirm_queue();
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS plata_currency_rates.audit_log;
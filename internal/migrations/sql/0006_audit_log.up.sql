@@ -0,0 +1,22 @@
+-- Журнал изменений курсов и реестра пар: кто, когда и с какого значения на какое поменял данные.
+CREATE TABLE IF NOT EXISTS plata_currency_rates.audit_log (
+    id bigserial NOT NULL,
+    actor text NOT NULL,
+    action text NOT NULL,
+    currency character(3),
+    base character(3),
+    old_value jsonb,
+    new_value jsonb,
+    request_id text,
+    created_dt timestamp without time zone NOT NULL DEFAULT current_timestamp,
+    CONSTRAINT audit_log_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_created_idx
+    ON plata_currency_rates.audit_log (created_dt);
+
+CREATE INDEX IF NOT EXISTS audit_log_pair_idx
+    ON plata_currency_rates.audit_log (currency, base, created_dt);
+
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx
+    ON plata_currency_rates.audit_log (actor, created_dt);
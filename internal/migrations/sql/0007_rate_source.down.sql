@@ -0,0 +1,22 @@
+DROP FUNCTION IF EXISTS plata_currency_rates.add_to_queue(uuid, character, character, numeric, text, timestamp without time zone);
+
+CREATE OR REPLACE FUNCTION plata_currency_rates.add_to_queue(_id uuid, _currency character, _base character, _rate numeric) RETURNS void
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    INSERT INTO plata_currency_rates.rates_queue(id, currency, base, rate, date)
+    VALUES (_id, _currency, _base, _rate, current_timestamp);
+END;
+$$;
+
+ALTER TABLE plata_currency_rates.rates_dead_letter
+    DROP COLUMN source,
+    DROP COLUMN published_dt;
+
+ALTER TABLE plata_currency_rates.rates_queue
+    DROP COLUMN source,
+    DROP COLUMN published_dt;
+
+ALTER TABLE plata_currency_rates.rates
+    DROP COLUMN source,
+    DROP COLUMN published_dt;
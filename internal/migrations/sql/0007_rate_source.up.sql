@@ -0,0 +1,26 @@
+-- Происхождение курса: имя провайдера, manual — ручная установка, backfill — загрузка истории.
+-- Производные курсы (derived) вычисляются на лету и не хранятся. У записей, сохранённых раньше, источник неизвестен.
+-- published_dt — дата публикации курса у провайдера, у ручных курсов пустая.
+ALTER TABLE plata_currency_rates.rates
+    ADD COLUMN source text NOT NULL DEFAULT 'unknown',
+    ADD COLUMN published_dt timestamp without time zone;
+
+ALTER TABLE plata_currency_rates.rates_queue
+    ADD COLUMN source text NOT NULL DEFAULT 'unknown',
+    ADD COLUMN published_dt timestamp without time zone;
+
+ALTER TABLE plata_currency_rates.rates_dead_letter
+    ADD COLUMN source text NOT NULL DEFAULT 'unknown',
+    ADD COLUMN published_dt timestamp without time zone;
+
+DROP FUNCTION IF EXISTS plata_currency_rates.add_to_queue(uuid, character, character, numeric);
+
+CREATE OR REPLACE FUNCTION plata_currency_rates.add_to_queue(_id uuid, _currency character, _base character, _rate numeric,
+                                                             _source text, _published_dt timestamp without time zone) RETURNS void
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    INSERT INTO plata_currency_rates.rates_queue(id, currency, base, rate, date, source, published_dt)
+    VALUES (_id, _currency, _base, _rate, current_timestamp, _source, _published_dt);
+END;
+$$;
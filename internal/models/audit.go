@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Действия, попадающие в журнал аудита
+const (
+	AuditRateRefresh       = "rate.refresh"        // запрос курса у провайдера и постановка в очередь
+	AuditRateUpdate        = "rate.update"         // ручная установка курса
+	AuditRatesBackfill     = "rates.backfill"      // загрузка истории курсов
+	AuditPairSave          = "pair.save"           // подписка, пауза или смена интервала пары
+	AuditPairArchive       = "pair.archive"        // снятие пары с отслеживания
+	AuditPairDelete        = "pair.delete"         // удаление пары вместе с историей
+	AuditDeadLetterRequeue = "dead_letter.requeue" // возврат строки из dead-letter в очередь
+)
+
+// AuditEntry — запись журнала изменений. OldValue и NewValue хранят JSON с изменёнными полями,
+// у созданных объектов нет старого значения, у удалённых — нового.
+type AuditEntry struct {
+	Id        int64           `json:"id" example:"42"`
+	Actor     string          `json:"actor" example:"backoffice"`
+	Action    string          `json:"action" example:"rate.update"`
+	Currency  string          `json:"currency,omitempty" example:"EUR"`
+	Base      string          `json:"base,omitempty" example:"USD"`
+	OldValue  json.RawMessage `json:"oldValue,omitempty" swaggertype:"object"`
+	NewValue  json.RawMessage `json:"newValue,omitempty" swaggertype:"object"`
+	RequestId string          `json:"requestId,omitempty" example:"3f1c9a52-7f0e-4c1b-9a51-0c4f3f2f6d7e"`
+	CreatedDt time.Time       `json:"createdDt" example:"2024-01-20 15:42:12.383064"`
+}
+
+// AuditFilter — условия выборки журнала; пустые поля не ограничивают выборку
+type AuditFilter struct {
+	Currency string
+	Base     string
+	Actor    string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
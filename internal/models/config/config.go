@@ -5,22 +5,40 @@ import (
 )
 
 type Config struct {
-	Application       Application
-	FrankfurterClient Provider
-	Postgres          Postgres
-	SyncRates         SyncRates
+	Application Application
+	Providers   []Provider
+	Postgres    Postgres
+	SyncRates   SyncRates
+	AutoUpdate  AutoUpdate
+	CrossRates  CrossRates
+	Health      Health
+	Tracing     Tracing
+	Auth        Auth
+	RateLimit   RateLimit
+	Cache       Cache
 }
 
 type Application struct {
-	Name        string
-	Version     string
-	Port        string
-	HttpTimeout time.Duration
+	Name            string
+	Version         string
+	Port            string
+	HttpTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	LogLevel        string
 }
 
+// ProviderTypeFrankfurter — единственный поддерживаемый тип провайдера
+const ProviderTypeFrankfurter = "frankfurter"
+
+var providerTypes = []string{ProviderTypeFrankfurter}
+
 type Provider struct {
+	Name      string
+	Type      string
+	Priority  int
 	Host      string
 	Endpoints map[string]Endpoint
+	RateLimit Limit // общий бюджет запросов к API провайдера со всех маршрутов и фоновых задач
 }
 
 type Endpoint struct {
@@ -34,8 +52,87 @@ type Postgres struct {
 	Database string
 	User     string
 	Password string
+	Pool     Pool
+}
+
+type Pool struct {
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	MaxConnIdleTime   time.Duration
+	HealthCheckPeriod time.Duration
+	AcquireTimeout    time.Duration
 }
 
 type SyncRates struct {
-	ConfigString string
+	ConfigString    string
+	BatchSize       int
+	MaxAttempts     int
+	RetryBackoff    time.Duration // задержка перед первым повтором, далее удваивается
+	MaxRetryBackoff time.Duration
+}
+
+type AutoUpdate struct {
+	Interval     time.Duration // интервал обновления пар, для которых он не задан явно
+	PollInterval time.Duration
+	PairTimeout  time.Duration // таймаут одного обновления: запроса котировок к базовой валюте и их сохранения
+	Jitter       float64       // доля интервала, на которую случайно сдвигается следующий запуск
+}
+
+type CrossRates struct {
+	Pivot string
+}
+
+type Health struct {
+	ProviderMaxAge  time.Duration
+	QueueMaxBacklog int64
+}
+
+type Tracing struct {
+	Exporter    string
+	Endpoint    string
+	Insecure    bool
+	SampleRatio float64
+}
+
+type Auth struct {
+	Enabled       bool
+	AnonymousRole string // роль запросов без учётных данных; пустая строка требует аутентификации на всех маршрутах API
+	APIKeys       []APIKey
+	JWT           JWT
+	CORSOrigins   []string
+}
+
+// APIKey хранит не сам ключ, а его SHA-256 в hex: echo -n "$KEY" | sha256sum
+type APIKey struct {
+	Name string
+	Hash string
+	Role string
+}
+
+type JWT struct {
+	Secret    string // общий секрет для HS256
+	JWKSFile  string // локальный JWKS с открытыми ключами RS256
+	Issuer    string
+	Audience  string
+	RoleClaim string
+}
+
+type RateLimit struct {
+	Enabled           bool
+	TrustForwardedFor bool             // брать адрес клиента из X-Forwarded-For, если сервис стоит за прокси
+	ClientIdleTTL     time.Duration    // через сколько забывать бакет клиента, от которого не было запросов
+	Default           Limit            // лимит маршрутов, для которых не задан свой
+	Routes            map[string]Limit // лимиты по имени маршрута: UpdateRate, Backfill…
+}
+
+// Limit — token bucket: RPS запросов в секунду в среднем и до Burst подряд; RPS = 0 снимает ограничение
+type Limit struct {
+	RPS   float64
+	Burst int
+}
+
+type Cache struct {
+	Enabled bool
+	TTL     time.Duration // сколько курс живёт в памяти; ограничивает отставание от записей других реплик
 }
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"time"
+)
+
+// Default возвращает конфигурацию, поверх которой накладываются TOML-файл и переменные окружения
+func Default() Config {
+	return Config{
+		Application: Application{
+			Name:            "currency-rates",
+			Version:         "dev",
+			Port:            ":8080",
+			HttpTimeout:     10 * time.Second,
+			ShutdownTimeout: 20 * time.Second,
+			LogLevel:        "trace",
+		},
+		Providers: []Provider{
+			{
+				Name:     "frankfurter",
+				Type:     ProviderTypeFrankfurter,
+				Priority: 1,
+				Host:     "https://api.frankfurter.app",
+				Endpoints: map[string]Endpoint{
+					"GetRate":         {Path: "/latest", Method: "GET"},
+					"GetCurrencyList": {Path: "/currencies", Method: "GET"},
+					"GetTimeSeries":   {Path: "/{start}..{end}", Method: "GET"},
+					"GetRatesAt":      {Path: "/{date}", Method: "GET"},
+				},
+				RateLimit: Limit{RPS: 5, Burst: 10},
+			},
+		},
+		Postgres: Postgres{
+			Host:     "localhost",
+			Port:     5432,
+			Database: "plata",
+			Pool: Pool{
+				MaxConns:       10,
+				MinConns:       2,
+				AcquireTimeout: 3 * time.Second,
+			},
+		},
+		SyncRates: SyncRates{
+			ConfigString:    "@every 15s",
+			BatchSize:       100,
+			MaxAttempts:     5,
+			RetryBackoff:    5 * time.Second,
+			MaxRetryBackoff: 5 * time.Minute,
+		},
+		AutoUpdate: AutoUpdate{
+			Interval:     30 * time.Second,
+			PollInterval: time.Second,
+			PairTimeout:  10 * time.Second,
+			Jitter:       0.1,
+		},
+		CrossRates: CrossRates{
+			Pivot: "EUR",
+		},
+		Health: Health{
+			ProviderMaxAge:  5 * time.Minute,
+			QueueMaxBacklog: 1000,
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			SampleRatio: 1,
+		},
+		Auth: Auth{
+			Enabled:       true,
+			AnonymousRole: "reader",
+			JWT: JWT{
+				RoleClaim: "role",
+			},
+			CORSOrigins: []string{"http://localhost"},
+		},
+		RateLimit: RateLimit{
+			Enabled:       true,
+			ClientIdleTTL: 10 * time.Minute,
+			Default:       Limit{RPS: 20, Burst: 40},
+		},
+		Cache: Cache{
+			Enabled: true,
+			TTL:     10 * time.Second,
+		},
+	}
+}
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/robfig/cron/v3"
+)
+
+const minJWTSecretLen = 32
+
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+// ValidationError перечисляет все некорректные поля конфигурации, а не только первое найденное
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var msg strings.Builder
+
+	msg.WriteString(fmt.Sprintf("invalid configuration, %d field(s) to fix:", len(e.Fields)))
+	for _, field := range e.Fields {
+		msg.WriteString(fmt.Sprintf("\n  %s: %s", field.Field, field.Reason))
+	}
+
+	return msg.String()
+}
+
+func (e *ValidationError) add(field, format string, args ...any) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Reason: fmt.Sprintf(format, args...)})
+}
+
+// Validate проверяет конфигурацию целиком и возвращает *ValidationError со списком всех ошибок
+func (cfg Config) Validate() error {
+	errs := &ValidationError{}
+
+	if _, port, err := net.SplitHostPort(cfg.Application.Port); err != nil || !validPort(port) {
+		errs.add("Application.Port", "expected [host]:port, got %q", cfg.Application.Port)
+	}
+	if cfg.Application.HttpTimeout <= 0 {
+		errs.add("Application.HttpTimeout", "must be positive")
+	}
+	if cfg.Application.ShutdownTimeout < 0 {
+		errs.add("Application.ShutdownTimeout", "must not be negative")
+	}
+
+	switch cfg.Application.LogLevel {
+	case "", "trace", "debug", "info", "warn", "error", "fatal", "panic", "disabled":
+	default:
+		errs.add("Application.LogLevel", "expected trace, debug, info, warn, error, fatal, panic or disabled, got %q", cfg.Application.LogLevel)
+	}
+
+	if len(cfg.Providers) == 0 {
+		errs.add("Providers", "at least one provider is required")
+	}
+	names := make(map[string]bool, len(cfg.Providers))
+	for i, prv := range cfg.Providers {
+		field := fmt.Sprintf("Providers[%d]", i)
+
+		if prv.Name == "" {
+			errs.add(field+".Name", "must not be empty")
+		} else if names[prv.Name] {
+			errs.add(field+".Name", "duplicate provider name %q", prv.Name)
+		}
+		names[prv.Name] = true
+
+		if prv.Type == "" {
+			errs.add(field+".Type", "must not be empty")
+		} else if !slices.Contains(providerTypes, prv.Type) {
+			errs.add(field+".Type", "unknown provider type %q, expected one of %s", prv.Type, strings.Join(providerTypes, ", "))
+		}
+		if u, err := url.Parse(prv.Host); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs.add(field+".Host", "expected absolute http(s) URL, got %q", prv.Host)
+		}
+		for name, endpoint := range prv.Endpoints {
+			if !strings.HasPrefix(endpoint.Path, "/") {
+				errs.add(fmt.Sprintf("%s.Endpoints.%s.Path", field, name), "must start with /, got %q", endpoint.Path)
+			}
+			if endpoint.Method == "" {
+				errs.add(fmt.Sprintf("%s.Endpoints.%s.Method", field, name), "must not be empty")
+			}
+		}
+	}
+
+	if cfg.Postgres.Host == "" {
+		errs.add("Postgres.Host", "must not be empty")
+	}
+	if !validPort(strconv.Itoa(cfg.Postgres.Port)) {
+		errs.add("Postgres.Port", "expected 1..65535, got %d", cfg.Postgres.Port)
+	}
+	if cfg.Postgres.Database == "" {
+		errs.add("Postgres.Database", "must not be empty")
+	}
+	if cfg.Postgres.User == "" {
+		errs.add("Postgres.User", "must not be empty")
+	}
+	if cfg.Postgres.Password == "" {
+		errs.add("Postgres.Password", "must not be empty")
+	}
+	if cfg.Postgres.Pool.MaxConns < 0 {
+		errs.add("Postgres.Pool.MaxConns", "must not be negative")
+	}
+	if cfg.Postgres.Pool.MinConns < 0 {
+		errs.add("Postgres.Pool.MinConns", "must not be negative")
+	}
+	if cfg.Postgres.Pool.MaxConns > 0 && cfg.Postgres.Pool.MinConns > cfg.Postgres.Pool.MaxConns {
+		errs.add("Postgres.Pool.MinConns", "must not exceed MaxConns (%d)", cfg.Postgres.Pool.MaxConns)
+	}
+
+	if _, err := cron.ParseStandard(cfg.SyncRates.ConfigString); err != nil {
+		errs.add("SyncRates.ConfigString", "expected cron spec or descriptor like @every 15s, got %q: %v", cfg.SyncRates.ConfigString, err)
+	}
+	if cfg.SyncRates.BatchSize <= 0 {
+		errs.add("SyncRates.BatchSize", "must be positive")
+	}
+	if cfg.SyncRates.MaxAttempts <= 0 {
+		errs.add("SyncRates.MaxAttempts", "must be positive")
+	}
+	if cfg.SyncRates.RetryBackoff <= 0 {
+		errs.add("SyncRates.RetryBackoff", "must be positive")
+	}
+	if cfg.SyncRates.MaxRetryBackoff < cfg.SyncRates.RetryBackoff {
+		errs.add("SyncRates.MaxRetryBackoff", "must not be less than RetryBackoff")
+	}
+
+	if cfg.AutoUpdate.Interval <= 0 {
+		errs.add("AutoUpdate.Interval", "must be positive")
+	}
+	if cfg.AutoUpdate.PollInterval <= 0 {
+		errs.add("AutoUpdate.PollInterval", "must be positive")
+	}
+	if cfg.AutoUpdate.PairTimeout <= 0 {
+		errs.add("AutoUpdate.PairTimeout", "must be positive")
+	}
+	if cfg.AutoUpdate.Jitter < 0 || cfg.AutoUpdate.Jitter >= 1 {
+		errs.add("AutoUpdate.Jitter", "expected 0..1 (exclusive), got %v", cfg.AutoUpdate.Jitter)
+	}
+
+	if pivot := cfg.CrossRates.Pivot; pivot != "" && (len(pivot) != 3 || strings.ToUpper(pivot) != pivot) {
+		errs.add("CrossRates.Pivot", "expected ISO 4217 code, got %q", pivot)
+	}
+
+	if cfg.Health.ProviderMaxAge < 0 {
+		errs.add("Health.ProviderMaxAge", "must not be negative")
+	}
+	if cfg.Health.QueueMaxBacklog < 0 {
+		errs.add("Health.QueueMaxBacklog", "must not be negative")
+	}
+
+	switch cfg.Tracing.Exporter {
+	case "", "none", "stdout":
+	case "otlp":
+		if cfg.Tracing.Endpoint == "" {
+			errs.add("Tracing.Endpoint", "required for otlp exporter")
+		}
+	default:
+		errs.add("Tracing.Exporter", "expected none, stdout or otlp, got %q", cfg.Tracing.Exporter)
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		errs.add("Tracing.SampleRatio", "expected 0..1, got %v", cfg.Tracing.SampleRatio)
+	}
+
+	validRole := func(role string) bool {
+		return role == "reader" || role == "operator" || role == "admin"
+	}
+	if cfg.Auth.AnonymousRole != "" && !validRole(cfg.Auth.AnonymousRole) {
+		errs.add("Auth.AnonymousRole", "expected reader, operator, admin or empty, got %q", cfg.Auth.AnonymousRole)
+	}
+	keyNames := make(map[string]bool, len(cfg.Auth.APIKeys))
+	for i, key := range cfg.Auth.APIKeys {
+		field := fmt.Sprintf("Auth.APIKeys[%d]", i)
+
+		if key.Name == "" {
+			errs.add(field+".Name", "must not be empty")
+		} else if keyNames[key.Name] {
+			errs.add(field+".Name", "duplicate key name %q", key.Name)
+		}
+		keyNames[key.Name] = true
+
+		if hash, err := hex.DecodeString(key.Hash); err != nil || len(hash) != sha256.Size {
+			errs.add(field+".Hash", "expected hex-encoded SHA-256 of the key")
+		}
+		if !validRole(key.Role) {
+			errs.add(field+".Role", "expected reader, operator or admin, got %q", key.Role)
+		}
+	}
+	// Ключ HS256 короче выхода SHA-256 подбирается перебором, RFC 7518 требует не меньше 256 бит
+	if cfg.Auth.JWT.Secret != "" && len(cfg.Auth.JWT.Secret) < minJWTSecretLen {
+		errs.add("Auth.JWT.Secret", "must be at least %d bytes long, got %d", minJWTSecretLen, len(cfg.Auth.JWT.Secret))
+	}
+	if cfg.Auth.JWT.RoleClaim == "" && (cfg.Auth.JWT.Secret != "" || cfg.Auth.JWT.JWKSFile != "") {
+		errs.add("Auth.JWT.RoleClaim", "required when JWT authentication is configured")
+	}
+	for i, origin := range cfg.Auth.CORSOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+			errs.add(fmt.Sprintf("Auth.CORSOrigins[%d]", i), "expected scheme://host[:port] or *, got %q", origin)
+		}
+	}
+
+	validateLimit := func(field string, limit Limit) {
+		if limit.RPS < 0 {
+			errs.add(field+".RPS", "must not be negative")
+		}
+		if limit.RPS > 0 && limit.Burst < 1 {
+			errs.add(field+".Burst", "must be at least 1 when RPS is set")
+		}
+	}
+	for i, prv := range cfg.Providers {
+		validateLimit(fmt.Sprintf("Providers[%d].RateLimit", i), prv.RateLimit)
+	}
+	if cfg.RateLimit.ClientIdleTTL <= 0 {
+		errs.add("RateLimit.ClientIdleTTL", "must be positive")
+	}
+	validateLimit("RateLimit.Default", cfg.RateLimit.Default)
+	for name, limit := range cfg.RateLimit.Routes {
+		validateLimit("RateLimit.Routes."+name, limit)
+	}
+
+	if cfg.Cache.Enabled && cfg.Cache.TTL <= 0 {
+		errs.add("Cache.TTL", "must be positive when cache is enabled")
+	}
+
+	if len(errs.Fields) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
@@ -0,0 +1,20 @@
+package models
+
+// defaultMinorUnits — количество знаков после запятой для большинства валют по ISO 4217
+const defaultMinorUnits = 2
+
+// minorUnits — валюты, у которых количество знаков после запятой по ISO 4217 отличается от двух
+var minorUnits = map[string]int32{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// MinorUnits возвращает количество знаков после запятой для валюты
+func MinorUnits(iso string) int32 {
+	if units, ok := minorUnits[iso]; ok {
+		return units
+	}
+
+	return defaultMinorUnits
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Статусы пары в реестре: active — обновляется автоматически, paused — отслеживается без автообновления,
+// archived — скрыта из списков, история курсов сохраняется
+const (
+	PairActive   = "active"
+	PairPaused   = "paused"
+	PairArchived = "archived"
+)
+
+type Pair struct {
+	Currency    string    `json:"currency" example:"EUR"`
+	Base        string    `json:"base" example:"USD"`
+	Status      string    `json:"status" example:"active"`
+	IntervalSec int32     `json:"intervalSec,omitempty" example:"300"` // 0 — интервал по умолчанию из конфигурации
+	CreatedDt   time.Time `json:"createdDt" example:"2024-01-20 15:42:12.383064"`
+	UpdateDt    time.Time `json:"updateDt" example:"2024-01-20 15:42:12.383064"`
+}
+
+type PairRequest struct {
+	Currency    string `json:"currency" example:"EUR"`
+	Base        string `json:"base" example:"USD"`
+	Status      string `json:"status" example:"active"` // active (по умолчанию) или paused
+	IntervalSec int32  `json:"intervalSec" example:"300"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrProviderThrottled — провайдер не стал обращаться к API, потому что исчерпан его собственный бюджет запросов.
+// Это не отказ API: реестр переходит к следующему провайдеру, не считая обращение ошибкой.
+var ErrProviderThrottled = errors.New("provider request budget exhausted")
+
+// ProviderRates — котировки, полученные от провайдера: сколько единиц каждой валюты из Rates стоят Amount единиц Base
+type ProviderRates struct {
+	Amount decimal.Decimal
+	Base   string
+	Date   time.Time
+	Rates  map[string]decimal.Decimal
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// QueueBatch — итог обработки одной пачки очереди
+type QueueBatch struct {
+	Applied      []CurrencyRateWithDt
+	Retried      int
+	DeadLettered int
+}
+
+// Size — количество строк очереди, взятых в пачку
+func (batch QueueBatch) Size() int {
+	return len(batch.Applied) + batch.Retried + batch.DeadLettered
+}
+
+type DeadLetter struct {
+	Id        string          `json:"id" example:"ed7f018b-dc91-4940-8d57-4f91cfe5a8bc"`
+	Currency  string          `json:"currency" example:"EUR"`
+	Base      string          `json:"base" example:"USD"`
+	Rate      decimal.Decimal `json:"rate" example:"0.91853"`
+	Source    string          `json:"source" example:"frankfurter"`
+	EnqueueDt time.Time       `json:"enqueueDt" example:"2024-01-20 15:42:12.383064"`
+	Attempts  int32           `json:"attempts" example:"5"`
+	LastError string          `json:"lastError" example:"duplicate key value violates unique constraint"`
+	FailedDt  time.Time       `json:"failedDt" example:"2024-01-20 15:52:12.383064"`
+}
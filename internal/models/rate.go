@@ -4,28 +4,50 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Источники курса помимо имени провайдера
+const (
+	RateSourceManual   = "manual"   // установлен вручную через PATCH /update
+	RateSourceBackfill = "backfill" // загружен из истории провайдера
+	RateSourceDerived  = "derived"  // вычислен через другие пары, в БД не хранится
+	RateSourceUnknown  = "unknown"  // сохранён до появления учёта источника
 )
 
 type CurrencyRate struct {
-	Id       string  `db:"id"`
-	Currency string  `db:"currency"`
-	Base     string  `db:"base"`
-	Rate     float32 `db:"rate"`
+	Id          string          `db:"id"`
+	Currency    string          `db:"currency"`
+	Base        string          `db:"base"`
+	Rate        decimal.Decimal `db:"rate"`
+	Provider    string          `db:"-"`
+	PublishedDt time.Time       `db:"-"` // дата публикации курса провайдером
 }
 
 type CurrencyRateDto struct {
-	Id       sql.NullString  `db:"id"`
-	Currency sql.NullString  `db:"currency"`
-	Base     sql.NullString  `db:"base"`
-	Rate     sql.NullFloat64 `db:"rate"`
+	Id       sql.NullString      `db:"id"`
+	Currency sql.NullString      `db:"currency"`
+	Base     sql.NullString      `db:"base"`
+	Rate     decimal.NullDecimal `db:"rate"`
 }
 
 type CurrencyRateWithDtDto struct {
-	Id       sql.NullString  `db:"id"`
-	Currency sql.NullString  `db:"currency"`
-	Base     sql.NullString  `db:"base"`
-	Rate     sql.NullFloat64 `db:"rate"`
-	UpdateDt sql.NullTime    `db:"date"`
+	Id          sql.NullString      `db:"id"`
+	Currency    sql.NullString      `db:"currency"`
+	Base        sql.NullString      `db:"base"`
+	Rate        decimal.NullDecimal `db:"rate"`
+	UpdateDt    sql.NullTime        `db:"date"`
+	Source      sql.NullString      `db:"source"`
+	PublishedDt sql.NullTime        `db:"published_dt"`
+}
+
+func (rate *CurrencyRateWithDtDto) publishedDt() *time.Time {
+	if !rate.PublishedDt.Valid {
+		return nil
+	}
+
+	return &rate.PublishedDt.Time
 }
 
 func (rate *CurrencyRateWithDtDto) FromDto() (CurrencyRateWithDt, error) {
@@ -34,11 +56,13 @@ func (rate *CurrencyRateWithDtDto) FromDto() (CurrencyRateWithDt, error) {
 	}
 
 	return CurrencyRateWithDt{
-		Id:       rate.Id.String,
-		Currency: rate.Currency.String,
-		Base:     rate.Base.String,
-		Rate:     rate.Rate.Float64,
-		UpdateDt: rate.UpdateDt.Time,
+		Id:          rate.Id.String,
+		Currency:    rate.Currency.String,
+		Base:        rate.Base.String,
+		Rate:        rate.Rate.Decimal,
+		UpdateDt:    rate.UpdateDt.Time,
+		Source:      rate.Source.String,
+		PublishedDt: rate.publishedDt(),
 	}, nil
 }
 
@@ -48,34 +72,89 @@ func (rate *CurrencyRateWithDtDto) FromDtoToLast() (CurrencyRateLast, error) {
 	}
 
 	return CurrencyRateLast{
-		Currency: rate.Currency.String,
-		Base:     rate.Base.String,
-		Rate:     rate.Rate.Float64,
-		UpdateDt: rate.UpdateDt.Time,
+		Currency:    rate.Currency.String,
+		Base:        rate.Base.String,
+		Rate:        rate.Rate.Decimal,
+		UpdateDt:    rate.UpdateDt.Time,
+		Source:      rate.Source.String,
+		PublishedDt: rate.publishedDt(),
 	}, nil
 }
 
 type CurrencyRateWithDt struct {
-	Id       string    `db:"id" json:"id" example:"ed7f018b-dc91-4940-8d57-4f91cfe5a8bc"`
-	Currency string    `db:"currency" json:"currency" example:"EUR"`
-	Base     string    `db:"base" json:"base" example:"USD"`
-	Rate     float64   `db:"rate" json:"rate" example:"0.91853"`
-	UpdateDt time.Time `db:"date" json:"updateDt" example:"2024-01-20 15:42:12.383064"`
+	Id          string          `db:"id" json:"id" example:"ed7f018b-dc91-4940-8d57-4f91cfe5a8bc"`
+	Currency    string          `db:"currency" json:"currency" example:"EUR"`
+	Base        string          `db:"base" json:"base" example:"USD"`
+	Rate        decimal.Decimal `db:"rate" json:"rate" example:"0.91853"`
+	UpdateDt    time.Time       `db:"date" json:"updateDt" example:"2024-01-20 15:42:12.383064"`
+	Source      string          `db:"source" json:"source" example:"frankfurter"`
+	PublishedDt *time.Time      `db:"published_dt" json:"publishedDt,omitempty" example:"2024-01-19T00:00:00Z"`
 }
 
 type CurrencyRateLast struct {
-	Currency  string    `db:"currency" json:"currency" example:"EUR"`
-	Base      string    `db:"base" json:"base" example:"USD"`
-	Rate      float64   `db:"rate" json:"rate" example:"0.91853"`
-	UpdateDt  time.Time `db:"date" json:"updateDt" example:"2024-01-20 15:42:12.383064"`
-	ChangePct float64   `json:"changePct" example:"1.23"`
+	Currency    string          `db:"currency" json:"currency" example:"EUR"`
+	Base        string          `db:"base" json:"base" example:"USD"`
+	Rate        decimal.Decimal `db:"rate" json:"rate" example:"0.91853"`
+	UpdateDt    time.Time       `db:"date" json:"updateDt" example:"2024-01-20 15:42:12.383064"`
+	Source      string          `db:"source" json:"source" example:"frankfurter"`
+	PublishedDt *time.Time      `db:"published_dt" json:"publishedDt,omitempty" example:"2024-01-19T00:00:00Z"`
+	ChangePct   float64         `json:"changePct" example:"1.23"`
+	Derived     bool            `json:"derived,omitempty" example:"true"`
+	Legs        []string        `json:"legs,omitempty" example:"EUR/USD,GBP/EUR"`
+}
+
+// LatestRates — последний и предыдущий курс пары; Previous пуст, если курс у пары один
+type LatestRates struct {
+	Last     CurrencyRateLast
+	Previous *CurrencyRateLast
 }
 
 type CurrencyRateWithChange struct {
-	Id        string    `json:"id"`
-	Currency  string    `json:"currency"`
-	Base      string    `json:"base"`
-	Rate      float64   `json:"rate"`
-	UpdateDt  time.Time `json:"updateDt"`
-	ChangePct float64   `json:"changePct"` // Изменение в процентах
+	Id        string          `json:"id"`
+	Currency  string          `json:"currency"`
+	Base      string          `json:"base"`
+	Rate      decimal.Decimal `json:"rate"`
+	UpdateDt  time.Time       `json:"updateDt"`
+	ChangePct float64         `json:"changePct"` // Изменение в процентах
+}
+
+type CurrencyRateAt struct {
+	Currency    string          `json:"currency" example:"EUR"`
+	Base        string          `json:"base" example:"USD"`
+	Rate        decimal.Decimal `json:"rate" example:"0.91853"`
+	UpdateDt    time.Time       `json:"updateDt" example:"2024-03-15T00:00:00Z"`
+	RequestedDt time.Time       `json:"requestedDt" example:"2024-03-15T23:59:59Z"`
+	Source      string          `json:"source" example:"local"`
+}
+
+// ChangePct возвращает изменение курса rate относительно prev в процентах
+func ChangePct(rate, prev decimal.Decimal) float64 {
+	if !prev.IsPositive() {
+		return 0
+	}
+
+	return rate.Sub(prev).Div(prev).Mul(decimal.NewFromInt(100)).InexactFloat64()
+}
+
+// Статусы запроса на обновление курса, поставленного в очередь
+const (
+	RateQueued   = "queued"
+	RateApplied  = "applied"
+	RateFailed   = "failed"
+	RateNotFound = "not_found"
+)
+
+// RateStatus — состояние запроса на обновление курса по его id
+type RateStatus struct {
+	Id            string           `json:"id" example:"ed7f018b-dc91-4940-8d57-4f91cfe5a8bc"`
+	Status        string           `json:"status" example:"queued"`
+	Currency      string           `json:"currency,omitempty" example:"EUR"`
+	Base          string           `json:"base,omitempty" example:"USD"`
+	Rate          *decimal.Decimal `json:"rate,omitempty" example:"0.91853"`
+	UpdateDt      *time.Time       `json:"updateDt,omitempty" example:"2024-01-20 15:42:12.383064"`
+	EnqueueDt     *time.Time       `json:"enqueueDt,omitempty" example:"2024-01-20 15:42:10.102331"`
+	QueuePosition int64            `json:"queuePosition,omitempty" example:"3"` // с единицы, только для queued
+	Attempts      int32            `json:"attempts,omitempty" example:"1"`
+	NextAttemptDt *time.Time       `json:"nextAttemptDt,omitempty" example:"2024-01-20 15:42:20.102331"`
+	LastError     *string          `json:"lastError,omitempty"`
 }
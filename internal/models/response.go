@@ -1,5 +1,29 @@
 package models
 
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
 type UpdateResponse struct {
-	RateId string `json:"rateId" example:"ed7f018b-dc91-4940-8d57-4f91cfe5a8bc"`
+	RateId   string `json:"rateId" example:"ed7f018b-dc91-4940-8d57-4f91cfe5a8bc"`
+	Provider string `json:"provider" example:"frankfurter"`
+}
+
+type BackfillResponse struct {
+	Received int    `json:"received" example:"128"`
+	Inserted int64  `json:"inserted" example:"120"`
+	Provider string `json:"provider" example:"frankfurter"`
+}
+
+type ConversionResponse struct {
+	From      string          `json:"from" example:"USD"`
+	To        string          `json:"to" example:"EUR"`
+	Amount    decimal.Decimal `json:"amount" example:"1234.56"`
+	Result    decimal.Decimal `json:"result" example:"1133.97"`
+	Rate      decimal.Decimal `json:"rate" example:"0.91853"`
+	RateDt    time.Time       `json:"rateDt" example:"2024-01-20 15:42:12.383064"`
+	Rounding  string          `json:"rounding" example:"half-even"`
+	Precision int32           `json:"precision" example:"2"`
 }
@@ -0,0 +1,242 @@
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models/config"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	latestPath     = "/latest"
+	currenciesPath = "/currencies"
+	timeSeriesPath = "/{start}..{end}"
+	ratesAtPath    = "/{date}"
+)
+
+// Server — httptest-сервер, отвечающий в формате API Frankfurter. Используется в тестах
+// вместо настоящего провайдера, в том числе для проверки переключения между провайдерами.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.RWMutex
+	rates   map[string]map[string]decimal.Decimal
+	failing bool
+}
+
+// NewServer поднимает сервер с котировками rates[base][currency]
+func NewServer(rates map[string]map[string]decimal.Decimal) *Server {
+	s := &Server{rates: make(map[string]map[string]decimal.Decimal, len(rates))}
+	for base, quotes := range rates {
+		for currency, rate := range quotes {
+			s.SetRate(currency, base, rate)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(latestPath, s.latest)
+	mux.HandleFunc(currenciesPath, s.currencies)
+	mux.HandleFunc("/", s.historical)
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+func (s *Server) SetRate(currency, base string, rate decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rates[base] == nil {
+		s.rates[base] = make(map[string]decimal.Decimal)
+	}
+	s.rates[base][currency] = rate
+}
+
+// SetFailing переключает сервер в режим, когда на любой запрос отвечаем 503
+func (s *Server) SetFailing(failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failing = failing
+}
+
+// Config возвращает конфигурацию провайдера, указывающую на этот сервер
+func (s *Server) Config(name string, priority int) config.Provider {
+	return config.Provider{
+		Name:     name,
+		Type:     "frankfurter",
+		Priority: priority,
+		Host:     s.URL,
+		Endpoints: map[string]config.Endpoint{
+			"GetRate":         {Path: latestPath, Method: http.MethodGet},
+			"GetCurrencyList": {Path: currenciesPath, Method: http.MethodGet},
+			"GetTimeSeries":   {Path: timeSeriesPath, Method: http.MethodGet},
+			"GetRatesAt":      {Path: ratesAtPath, Method: http.MethodGet},
+		},
+	}
+}
+
+func (s *Server) latest(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.failing {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	base := r.URL.Query().Get("from")
+	quotes, ok := s.rates[base]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	rates := pickQuotes(quotes, r.URL.Query().Get("to"))
+
+	writeJson(w, map[string]interface{}{
+		"amount": 1.0,
+		"base":   base,
+		"date":   time.Now().Format(time.DateOnly),
+		"rates":  rates,
+	})
+}
+
+// historical обслуживает оба исторических эндпоинта: /{date} и /{start}..{end}
+func (s *Server) historical(w http.ResponseWriter, r *http.Request) {
+	if strings.Contains(r.URL.Path, "..") {
+		s.timeSeries(w, r)
+		return
+	}
+
+	s.ratesAt(w, r)
+}
+
+// ratesAt отдаёт текущие котировки с датой последнего рабочего дня не позже запрошенного
+func (s *Server) ratesAt(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.failing {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	date, err := time.Parse(time.DateOnly, strings.TrimPrefix(r.URL.Path, "/"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	for date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		date = date.AddDate(0, 0, -1)
+	}
+
+	base := r.URL.Query().Get("from")
+	quotes, ok := s.rates[base]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	rates := pickQuotes(quotes, r.URL.Query().Get("to"))
+
+	writeJson(w, map[string]interface{}{
+		"amount": 1.0,
+		"base":   base,
+		"date":   date.Format(time.DateOnly),
+		"rates":  rates,
+	})
+}
+
+// timeSeries отдаёт текущие котировки на каждый рабочий день периода, как это делает Frankfurter
+func (s *Server) timeSeries(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.failing {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	bounds := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "..")
+	if len(bounds) != 2 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	start, errStart := time.Parse(time.DateOnly, bounds[0])
+	end, errEnd := time.Parse(time.DateOnly, bounds[1])
+	if errStart != nil || errEnd != nil || end.Before(start) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	base := r.URL.Query().Get("from")
+	quotes, ok := s.rates[base]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	series := make(map[string]map[string]json.Number)
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+
+		series[day.Format(time.DateOnly)] = pickQuotes(quotes, r.URL.Query().Get("to"))
+	}
+
+	writeJson(w, map[string]interface{}{
+		"amount":     1.0,
+		"base":       base,
+		"start_date": start.Format(time.DateOnly),
+		"end_date":   end.Format(time.DateOnly),
+		"rates":      series,
+	})
+}
+
+func (s *Server) currencies(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.failing {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	currencies := make(map[string]string)
+	for base, quotes := range s.rates {
+		currencies[base] = base
+		for currency := range quotes {
+			currencies[currency] = currency
+		}
+	}
+
+	writeJson(w, currencies)
+}
+
+// pickQuotes выбирает котировки валют из списка symbols. Значения отдаются JSON-числами, как у Frankfurter.
+func pickQuotes(quotes map[string]decimal.Decimal, symbols string) map[string]json.Number {
+	rates := make(map[string]json.Number)
+	for _, currency := range strings.Split(symbols, ",") {
+		if rate, ok := quotes[currency]; ok {
+			rates[currency] = json.Number(rate.String())
+		}
+	}
+
+	return rates
+}
+
+func writeJson(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
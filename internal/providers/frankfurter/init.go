@@ -1,30 +1,81 @@
 package frankfurter
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/Hashira21/currency-rate/internal/infrastructure/requester"
+	"github.com/Hashira21/currency-rate/internal/models"
 	"github.com/Hashira21/currency-rate/internal/models/config"
 	"github.com/rs/zerolog"
+	"golang.org/x/time/rate"
 )
 
 const (
+	Type = config.ProviderTypeFrankfurter
+
 	prvTimeout = 5 * time.Second
 )
 
-type provider struct {
+type endpoints struct {
 	getRate         requester.Requester
 	getCurrencyList requester.Requester
-	logger          zerolog.Logger
+	getTimeSeries   requester.Requester
+	getRatesAt      requester.Requester
+}
+
+type provider struct {
+	// Набор эндпоинтов подменяется целиком при смене адреса API, запросы в процессе работают со старым набором
+	endpoints atomic.Pointer[endpoints]
+	// Общий бюджет запросов к API: автообновление, запросы клиентов и загрузка истории делят его между собой
+	limiter *rate.Limiter
+	logger  zerolog.Logger
 }
 
 func NewProvider(providerCfg *config.Provider, logger zerolog.Logger) *provider {
 	httpClient := http.Client{Timeout: prvTimeout}
 
-	return &provider{
+	limit := rate.Inf
+	if providerCfg.RateLimit.RPS > 0 {
+		limit = rate.Limit(providerCfg.RateLimit.RPS)
+	}
+
+	prv := &provider{
+		limiter: rate.NewLimiter(limit, providerCfg.RateLimit.Burst),
+		logger:  logger,
+	}
+	prv.endpoints.Store(&endpoints{
 		requester.New(&httpClient, *providerCfg, "GetRate"),
 		requester.New(&httpClient, *providerCfg, "GetCurrencyList"),
-		logger,
+		requester.New(&httpClient, *providerCfg, "GetTimeSeries"),
+		requester.New(&httpClient, *providerCfg, "GetRatesAt"),
+	})
+
+	return prv
+}
+
+// SetHost переключает все эндпоинты провайдера на новый адрес API
+func (prv *provider) SetHost(host string) {
+	current := prv.endpoints.Load()
+
+	prv.endpoints.Store(&endpoints{
+		current.getRate.SetHost(host),
+		current.getCurrencyList.SetHost(host),
+		current.getTimeSeries.SetHost(host),
+		current.getRatesAt.SetHost(host),
+	})
+}
+
+// throttle ждёт, пока бюджет запросов к API позволит сделать ещё один. Если ожидание не укладывается
+// в дедлайн ctx, сразу возвращает models.ErrProviderThrottled, и запрос уходит следующему по приоритету провайдеру.
+// Ошибку логирует реестр.
+func (prv *provider) throttle(ctx context.Context) error {
+	if err := prv.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("%w: %w", models.ErrProviderThrottled, err)
 	}
+
+	return nil
 }
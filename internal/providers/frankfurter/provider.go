@@ -5,66 +5,220 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models"
 )
 
 const amount = "1"
 
-func (prv *provider) GetRate(ctx context.Context, toIso, fromIso string) ([]byte, error) {
+func (prv *provider) GetRate(ctx context.Context, toIso, fromIso string) (models.ProviderRates, error) {
+	rates, err := prv.GetRates(ctx, fromIso, []string{toIso})
+	if err != nil {
+		return models.ProviderRates{}, err
+	}
+
+	if _, ok := rates.Rates[toIso]; !ok {
+		_err := &ResponseError{Field: "rates", Reason: fmt.Sprintf("has no quote for %s", toIso)}
+		prv.logger.Error().Ctx(ctx).Msg(_err.Error())
+		return models.ProviderRates{}, _err
+	}
+
+	return rates, nil
+}
+
+// GetRates запрашивает котировки сразу нескольких валют к fromIso одним запросом
+func (prv *provider) GetRates(ctx context.Context, fromIso string, toIsos []string) (models.ProviderRates, error) {
+	if err := prv.throttle(ctx); err != nil {
+		return models.ProviderRates{}, err
+	}
+
+	rqCtx, cancel := context.WithTimeout(ctx, prvTimeout)
+	defer cancel()
+
+	params := make(url.Values, 3)
+	params.Add("amount", amount)
+	params.Add("from", fromIso)
+	params.Add("to", strings.Join(toIsos, ","))
+
+	resp, err := prv.endpoints.Load().getRate.
+		SetQueryParameters(params).
+		DoWithoutBody(rqCtx)
+	if err != nil {
+		prv.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.ProviderRates{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 200 {
+		_err := fmt.Errorf("unexpected status code from provider: %s", resp.Status)
+		prv.logger.Error().Ctx(ctx).Msg(_err.Error())
+		return models.ProviderRates{}, _err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		prv.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.ProviderRates{}, err
+	}
+
+	rates, err := decodeRates(respBody)
+	if err != nil {
+		prv.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.ProviderRates{}, err
+	}
+
+	if rates.Base != fromIso {
+		_err := &ResponseError{Field: "base", Reason: fmt.Sprintf("expected %s, got %s", fromIso, rates.Base)}
+		prv.logger.Error().Ctx(ctx).Msg(_err.Error())
+		return models.ProviderRates{}, _err
+	}
+
+	return rates, nil
+}
+
+// GetRatesAt запрашивает котировки, опубликованные на дату date. Если в этот день котировок не было
+// (выходной или праздник), Frankfurter возвращает последние опубликованные до него.
+func (prv *provider) GetRatesAt(ctx context.Context, fromIso string, toIsos []string, date time.Time) (models.ProviderRates, error) {
+	if err := prv.throttle(ctx); err != nil {
+		return models.ProviderRates{}, err
+	}
+
+	rqCtx, cancel := context.WithTimeout(ctx, prvTimeout)
+	defer cancel()
+
+	params := make(url.Values, 3)
+	params.Add("amount", amount)
+	params.Add("from", fromIso)
+	params.Add("to", strings.Join(toIsos, ","))
+
+	resp, err := prv.endpoints.Load().getRatesAt.
+		SetPathParameters(map[string]string{"date": date.Format(time.DateOnly)}).
+		SetQueryParameters(params).
+		DoWithoutBody(rqCtx)
+	if err != nil {
+		prv.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.ProviderRates{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 200 {
+		_err := fmt.Errorf("unexpected status code from provider: %s", resp.Status)
+		prv.logger.Error().Ctx(ctx).Msg(_err.Error())
+		return models.ProviderRates{}, _err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		prv.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.ProviderRates{}, err
+	}
+
+	rates, err := decodeRates(respBody)
+	if err != nil {
+		prv.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.ProviderRates{}, err
+	}
+
+	if rates.Base != fromIso {
+		_err := &ResponseError{Field: "base", Reason: fmt.Sprintf("expected %s, got %s", fromIso, rates.Base)}
+		prv.logger.Error().Ctx(ctx).Msg(_err.Error())
+		return models.ProviderRates{}, _err
+	}
+
+	return rates, nil
+}
+
+// GetTimeSeries запрашивает дневные котировки за период [start, end]
+func (prv *provider) GetTimeSeries(ctx context.Context, fromIso string, toIsos []string, start, end time.Time) ([]models.ProviderRates, error) {
+	if err := prv.throttle(ctx); err != nil {
+		return nil, err
+	}
+
 	rqCtx, cancel := context.WithTimeout(ctx, prvTimeout)
 	defer cancel()
 
 	params := make(url.Values, 3)
 	params.Add("amount", amount)
 	params.Add("from", fromIso)
-	params.Add("to", toIso)
+	params.Add("to", strings.Join(toIsos, ","))
 
-	resp, err := prv.getRate.
+	resp, err := prv.endpoints.Load().getTimeSeries.
+		SetPathParameters(map[string]string{
+			"start": start.Format(time.DateOnly),
+			"end":   end.Format(time.DateOnly),
+		}).
 		SetQueryParameters(params).
 		DoWithoutBody(rqCtx)
 	if err != nil {
-		prv.logger.Error().Msg(err.Error())
+		prv.logger.Error().Ctx(ctx).Msg(err.Error())
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode > 200 {
 		_err := fmt.Errorf("unexpected status code from provider: %s", resp.Status)
-		prv.logger.Error().Msg(_err.Error())
+		prv.logger.Error().Ctx(ctx).Msg(_err.Error())
 		return nil, _err
 	}
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		prv.logger.Error().Msg(err.Error())
+		prv.logger.Error().Ctx(ctx).Msg(err.Error())
 		return nil, err
 	}
 
-	return respBody, err
+	series, err := decodeTimeSeries(respBody)
+	if err != nil {
+		prv.logger.Error().Ctx(ctx).Msg(err.Error())
+		return nil, err
+	}
+
+	for i := range series {
+		if series[i].Base != fromIso {
+			_err := &ResponseError{Field: "base", Reason: fmt.Sprintf("expected %s, got %s", fromIso, series[i].Base)}
+			prv.logger.Error().Ctx(ctx).Msg(_err.Error())
+			return nil, _err
+		}
+	}
+
+	return series, nil
 }
 
-func (prv *provider) GetCurrencyList(ctx context.Context) ([]byte, error) {
+func (prv *provider) GetCurrencyList(ctx context.Context) (map[string]string, error) {
+	if err := prv.throttle(ctx); err != nil {
+		return nil, err
+	}
+
 	rqCtx, cancel := context.WithTimeout(ctx, prvTimeout)
 	defer cancel()
 
-	resp, err := prv.getCurrencyList.
+	resp, err := prv.endpoints.Load().getCurrencyList.
 		DoWithoutBody(rqCtx)
 	if err != nil {
-		prv.logger.Error().Msg(err.Error())
+		prv.logger.Error().Ctx(ctx).Msg(err.Error())
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode > 200 {
 		_err := fmt.Errorf("unexpected status code from provider: %s", resp.Status)
-		prv.logger.Error().Msg(_err.Error())
+		prv.logger.Error().Ctx(ctx).Msg(_err.Error())
 		return nil, _err
 	}
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		prv.logger.Error().Msg(err.Error())
+		prv.logger.Error().Ctx(ctx).Msg(err.Error())
+		return nil, err
+	}
+
+	currencies, err := decodeCurrencyList(respBody)
+	if err != nil {
+		prv.logger.Error().Ctx(ctx).Msg(err.Error())
 		return nil, err
 	}
 
-	return respBody, err
+	return currencies, nil
 }
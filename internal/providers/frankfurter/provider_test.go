@@ -0,0 +1,109 @@
+package frankfurter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/Hashira21/currency-rate/internal/models/config"
+	"github.com/Hashira21/currency-rate/internal/providers/fake"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+)
+
+func newFakeProvider(t *testing.T) (*provider, *fake.Server) {
+	t.Helper()
+
+	srv := fake.NewServer(map[string]map[string]decimal.Decimal{
+		"EUR": {"USD": decimal.RequireFromString("1.0831"), "GBP": decimal.RequireFromString("0.8552")},
+	})
+	t.Cleanup(srv.Close)
+
+	cfg := srv.Config("fake", 1)
+	return NewProvider(&cfg, zerolog.Nop()), srv
+}
+
+func TestProviderGetRates(t *testing.T) {
+	prv, _ := newFakeProvider(t)
+
+	rates, err := prv.GetRates(context.Background(), "EUR", []string{"USD", "GBP"})
+	if err != nil {
+		t.Fatalf("GetRates() error = %v", err)
+	}
+	if len(rates.Rates) != 2 || !rates.Rates["GBP"].Equal(decimal.RequireFromString("0.8552")) {
+		t.Errorf("GetRates() rates = %v", rates.Rates)
+	}
+}
+
+func TestProviderGetRateMissingQuote(t *testing.T) {
+	prv, _ := newFakeProvider(t)
+
+	if _, err := prv.GetRate(context.Background(), "JPY", "EUR"); err == nil {
+		t.Error("GetRate() for unknown quote = nil, want error")
+	}
+}
+
+func TestProviderGetRatesAtWeekend(t *testing.T) {
+	prv, _ := newFakeProvider(t)
+
+	sunday := time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)
+	rates, err := prv.GetRatesAt(context.Background(), "EUR", []string{"USD"}, sunday)
+	if err != nil {
+		t.Fatalf("GetRatesAt() error = %v", err)
+	}
+	if friday := sunday.AddDate(0, 0, -2); !rates.Date.Equal(friday) {
+		t.Errorf("GetRatesAt() date = %s, want %s", rates.Date, friday)
+	}
+}
+
+func TestProviderGetTimeSeries(t *testing.T) {
+	prv, _ := newFakeProvider(t)
+
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	series, err := prv.GetTimeSeries(context.Background(), "EUR", []string{"USD"}, start, start.AddDate(0, 0, 6))
+	if err != nil {
+		t.Fatalf("GetTimeSeries() error = %v", err)
+	}
+	if len(series) != 5 {
+		t.Errorf("GetTimeSeries() len = %d, want 5 business days", len(series))
+	}
+}
+
+func TestProviderUnavailable(t *testing.T) {
+	prv, srv := newFakeProvider(t)
+	srv.SetFailing(true)
+
+	if _, err := prv.GetRates(context.Background(), "EUR", []string{"USD"}); err == nil {
+		t.Error("GetRates() with failing server = nil, want error")
+	}
+	if _, err := prv.GetCurrencyList(context.Background()); err == nil {
+		t.Error("GetCurrencyList() with failing server = nil, want error")
+	}
+}
+
+func TestProviderThrottle(t *testing.T) {
+	srv := fake.NewServer(map[string]map[string]decimal.Decimal{"EUR": {"USD": decimal.RequireFromString("1.0831")}})
+	t.Cleanup(srv.Close)
+
+	cfg := srv.Config("fake", 1)
+	cfg.RateLimit = config.Limit{RPS: 0.01, Burst: 1}
+	prv := NewProvider(&cfg, zerolog.Nop())
+
+	if _, err := prv.GetRate(context.Background(), "USD", "EUR"); err != nil {
+		t.Fatalf("GetRate() within budget error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := prv.GetRate(ctx, "USD", "EUR")
+	if !errors.Is(err, models.ErrProviderThrottled) {
+		t.Fatalf("GetRate() over budget error = %v, want %v", err, models.ErrProviderThrottled)
+	}
+	if waited := time.Since(start); waited > 500*time.Millisecond {
+		t.Errorf("GetRate() waited %s for a token that cannot arrive before the deadline", waited)
+	}
+}
@@ -0,0 +1,155 @@
+package frankfurter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidResponse = errors.New("invalid response from frankfurter")
+
+	isoCodeRe = regexp.MustCompile("^[A-Z]{3}$")
+)
+
+// ResponseError описывает, какое поле ответа Frankfurter не прошло проверку
+type ResponseError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s: field %q %s", ErrInvalidResponse.Error(), e.Field, e.Reason)
+}
+
+func (e *ResponseError) Unwrap() error {
+	return ErrInvalidResponse
+}
+
+type ratesResponse struct {
+	Amount decimal.NullDecimal        `json:"amount"`
+	Base   string                     `json:"base"`
+	Date   string                     `json:"date"`
+	Rates  map[string]decimal.Decimal `json:"rates"`
+}
+
+func decodeRates(body []byte) (models.ProviderRates, error) {
+	var resp ratesResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return models.ProviderRates{}, &ResponseError{Field: "body", Reason: err.Error()}
+	}
+
+	if !resp.Amount.Valid || !resp.Amount.Decimal.IsPositive() {
+		return models.ProviderRates{}, &ResponseError{Field: "amount", Reason: "must be positive"}
+	}
+
+	if !isoCodeRe.MatchString(resp.Base) {
+		return models.ProviderRates{}, &ResponseError{Field: "base", Reason: fmt.Sprintf("is not an iso code: %q", resp.Base)}
+	}
+
+	date, err := time.Parse(time.DateOnly, resp.Date)
+	if err != nil {
+		return models.ProviderRates{}, &ResponseError{Field: "date", Reason: err.Error()}
+	}
+
+	if len(resp.Rates) == 0 {
+		return models.ProviderRates{}, &ResponseError{Field: "rates", Reason: "is empty"}
+	}
+
+	for currency, rate := range resp.Rates {
+		if !isoCodeRe.MatchString(currency) {
+			return models.ProviderRates{}, &ResponseError{Field: "rates", Reason: fmt.Sprintf("has invalid iso code %q", currency)}
+		}
+
+		if !rate.IsPositive() {
+			return models.ProviderRates{}, &ResponseError{Field: "rates." + currency, Reason: "must be positive"}
+		}
+	}
+
+	return models.ProviderRates{
+		Amount: resp.Amount.Decimal,
+		Base:   resp.Base,
+		Date:   date,
+		Rates:  resp.Rates,
+	}, nil
+}
+
+type timeSeriesResponse struct {
+	Amount    decimal.NullDecimal                   `json:"amount"`
+	Base      string                                `json:"base"`
+	StartDate string                                `json:"start_date"`
+	EndDate   string                                `json:"end_date"`
+	Rates     map[string]map[string]decimal.Decimal `json:"rates"`
+}
+
+// decodeTimeSeries разбирает ответ /{start}..{end} в список котировок по дням, отсортированный по дате
+func decodeTimeSeries(body []byte) ([]models.ProviderRates, error) {
+	var resp timeSeriesResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, &ResponseError{Field: "body", Reason: err.Error()}
+	}
+
+	if !resp.Amount.Valid || !resp.Amount.Decimal.IsPositive() {
+		return nil, &ResponseError{Field: "amount", Reason: "must be positive"}
+	}
+
+	if !isoCodeRe.MatchString(resp.Base) {
+		return nil, &ResponseError{Field: "base", Reason: fmt.Sprintf("is not an iso code: %q", resp.Base)}
+	}
+
+	series := make([]models.ProviderRates, 0, len(resp.Rates))
+	for day, quotes := range resp.Rates {
+		date, err := time.Parse(time.DateOnly, day)
+		if err != nil {
+			return nil, &ResponseError{Field: "rates", Reason: err.Error()}
+		}
+
+		for currency, rate := range quotes {
+			if !isoCodeRe.MatchString(currency) {
+				return nil, &ResponseError{Field: "rates." + day, Reason: fmt.Sprintf("has invalid iso code %q", currency)}
+			}
+
+			if !rate.IsPositive() {
+				return nil, &ResponseError{Field: "rates." + day + "." + currency, Reason: "must be positive"}
+			}
+		}
+
+		series = append(series, models.ProviderRates{
+			Amount: resp.Amount.Decimal,
+			Base:   resp.Base,
+			Date:   date,
+			Rates:  quotes,
+		})
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].Date.Before(series[j].Date)
+	})
+
+	return series, nil
+}
+
+func decodeCurrencyList(body []byte) (map[string]string, error) {
+	var currencies map[string]string
+	if err := json.Unmarshal(body, &currencies); err != nil {
+		return nil, &ResponseError{Field: "body", Reason: err.Error()}
+	}
+
+	if len(currencies) == 0 {
+		return nil, &ResponseError{Field: "body", Reason: "is empty"}
+	}
+
+	for iso := range currencies {
+		if !isoCodeRe.MatchString(iso) {
+			return nil, &ResponseError{Field: "body", Reason: fmt.Sprintf("has invalid iso code %q", iso)}
+		}
+	}
+
+	return currencies, nil
+}
@@ -0,0 +1,97 @@
+package frankfurter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestDecodeRates(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantField string
+	}{
+		{name: "valid", body: `{"amount":1,"base":"EUR","date":"2024-03-01","rates":{"USD":1.0831}}`},
+		{name: "malformed json", body: `{"amount":`, wantField: "body"},
+		{name: "missing amount", body: `{"base":"EUR","date":"2024-03-01","rates":{"USD":1.08}}`, wantField: "amount"},
+		{name: "zero amount", body: `{"amount":0,"base":"EUR","date":"2024-03-01","rates":{"USD":1.08}}`, wantField: "amount"},
+		{name: "bad base", body: `{"amount":1,"base":"eur","date":"2024-03-01","rates":{"USD":1.08}}`, wantField: "base"},
+		{name: "bad date", body: `{"amount":1,"base":"EUR","date":"01.03.2024","rates":{"USD":1.08}}`, wantField: "date"},
+		{name: "empty rates", body: `{"amount":1,"base":"EUR","date":"2024-03-01","rates":{}}`, wantField: "rates"},
+		{name: "bad currency", body: `{"amount":1,"base":"EUR","date":"2024-03-01","rates":{"US":1.08}}`, wantField: "rates"},
+		{name: "negative rate", body: `{"amount":1,"base":"EUR","date":"2024-03-01","rates":{"USD":-1}}`, wantField: "rates.USD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rates, err := decodeRates([]byte(tt.body))
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("decodeRates() error = %v", err)
+				}
+				if !rates.Rates["USD"].Equal(decimal.RequireFromString("1.0831")) {
+					t.Errorf("decodeRates() USD = %s, want 1.0831", rates.Rates["USD"])
+				}
+				if want := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC); !rates.Date.Equal(want) {
+					t.Errorf("decodeRates() date = %s, want %s", rates.Date, want)
+				}
+				return
+			}
+
+			var respErr *ResponseError
+			if !errors.As(err, &respErr) || !errors.Is(err, ErrInvalidResponse) {
+				t.Fatalf("decodeRates() error = %v, want *ResponseError", err)
+			}
+			if respErr.Field != tt.wantField {
+				t.Errorf("decodeRates() field = %q, want %q", respErr.Field, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestDecodeTimeSeries(t *testing.T) {
+	body := `{"amount":1,"base":"EUR","start_date":"2024-03-01","end_date":"2024-03-04",
+		"rates":{"2024-03-04":{"USD":1.09},"2024-03-01":{"USD":1.08}}}`
+
+	series, err := decodeTimeSeries([]byte(body))
+	if err != nil {
+		t.Fatalf("decodeTimeSeries() error = %v", err)
+	}
+	if len(series) != 2 {
+		t.Fatalf("decodeTimeSeries() len = %d, want 2", len(series))
+	}
+	if !series[0].Date.Before(series[1].Date) {
+		t.Errorf("decodeTimeSeries() not sorted by date: %s, %s", series[0].Date, series[1].Date)
+	}
+
+	_, err = decodeTimeSeries([]byte(`{"amount":1,"base":"EUR","rates":{"2024-03-01":{"USD":0}}}`))
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) || respErr.Field != "rates.2024-03-01.USD" {
+		t.Errorf("decodeTimeSeries() error = %v, want zero rate rejected", err)
+	}
+}
+
+func TestDecodeCurrencyList(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{name: "valid", body: `{"EUR":"Euro","USD":"United States Dollar"}`},
+		{name: "empty", body: `{}`, wantErr: true},
+		{name: "bad iso code", body: `{"euro":"Euro"}`, wantErr: true},
+		{name: "not an object", body: `[]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCurrencyList([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("decodeCurrencyList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package providers
+
+import (
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+type entry struct {
+	name     string
+	priority int
+	provider Provider
+}
+
+type Registry struct {
+	entries []entry
+	logger  zerolog.Logger
+
+	// Время последнего успешного и последнего неудачного обращения к провайдерам, unix nano
+	lastSuccess atomic.Int64
+	lastFailure atomic.Int64
+}
+
+func New(logger zerolog.Logger) *Registry {
+	return &Registry{
+		logger: logger,
+	}
+}
@@ -0,0 +1,21 @@
+package providers
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models"
+)
+
+type Provider interface {
+	GetRate(ctx context.Context, toIso, fromIso string) (models.ProviderRates, error)
+	GetRates(ctx context.Context, fromIso string, toIsos []string) (models.ProviderRates, error)
+	GetRatesAt(ctx context.Context, fromIso string, toIsos []string, date time.Time) (models.ProviderRates, error)
+	GetTimeSeries(ctx context.Context, fromIso string, toIsos []string, start, end time.Time) ([]models.ProviderRates, error)
+	GetCurrencyList(ctx context.Context) (map[string]string, error)
+}
+
+// HostSetter реализуют провайдеры, у которых можно сменить адрес API без пересоздания
+type HostSetter interface {
+	SetHost(host string)
+}
@@ -0,0 +1,193 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/infrastructure/metrics"
+	"github.com/Hashira21/currency-rate/internal/models"
+)
+
+var (
+	ErrNoProviders = errors.New("no rate providers registered")
+	ErrAllFailed   = errors.New("all rate providers failed")
+)
+
+// Register добавляет провайдера в реестр. Чем меньше priority, тем раньше к нему обращаемся.
+func (r *Registry) Register(name string, priority int, provider Provider) {
+	r.entries = append(r.entries, entry{name: name, priority: priority, provider: provider})
+
+	sort.SliceStable(r.entries, func(i, j int) bool {
+		return r.entries[i].priority < r.entries[j].priority
+	})
+}
+
+func (r *Registry) Len() int {
+	return len(r.entries)
+}
+
+// SetHost меняет адрес API провайдера name на лету
+func (r *Registry) SetHost(name, host string) error {
+	for _, e := range r.entries {
+		if e.name != name {
+			continue
+		}
+
+		setter, ok := e.provider.(HostSetter)
+		if !ok {
+			return fmt.Errorf("provider %s does not support changing host", name)
+		}
+		setter.SetHost(host)
+
+		return nil
+	}
+
+	return fmt.Errorf("provider %s is not registered", name)
+}
+
+// GetRate опрашивает провайдеров по приоритету и возвращает ответ первого успешного вместе с его именем
+func (r *Registry) GetRate(ctx context.Context, toIso, fromIso string) (models.ProviderRates, string, error) {
+	var name string
+	var rates models.ProviderRates
+
+	err := r.each(ctx, "GetRate", func(e entry) error {
+		res, err := e.provider.GetRate(ctx, toIso, fromIso)
+		if err != nil {
+			return err
+		}
+
+		name, rates = e.name, res
+		return nil
+	})
+
+	return rates, name, err
+}
+
+// GetRates — пакетный вариант GetRate: все котировки для одной базовой валюты за один запрос
+func (r *Registry) GetRates(ctx context.Context, fromIso string, toIsos []string) (models.ProviderRates, string, error) {
+	var name string
+	var rates models.ProviderRates
+
+	err := r.each(ctx, "GetRates", func(e entry) error {
+		res, err := e.provider.GetRates(ctx, fromIso, toIsos)
+		if err != nil {
+			return err
+		}
+
+		name, rates = e.name, res
+		return nil
+	})
+
+	return rates, name, err
+}
+
+func (r *Registry) GetRatesAt(ctx context.Context, fromIso string, toIsos []string, date time.Time) (models.ProviderRates, string, error) {
+	var name string
+	var rates models.ProviderRates
+
+	err := r.each(ctx, "GetRatesAt", func(e entry) error {
+		res, err := e.provider.GetRatesAt(ctx, fromIso, toIsos, date)
+		if err != nil {
+			return err
+		}
+
+		name, rates = e.name, res
+		return nil
+	})
+
+	return rates, name, err
+}
+
+func (r *Registry) GetTimeSeries(ctx context.Context, fromIso string, toIsos []string, start, end time.Time) ([]models.ProviderRates, string, error) {
+	var name string
+	var series []models.ProviderRates
+
+	err := r.each(ctx, "GetTimeSeries", func(e entry) error {
+		res, err := e.provider.GetTimeSeries(ctx, fromIso, toIsos, start, end)
+		if err != nil {
+			return err
+		}
+
+		name, series = e.name, res
+		return nil
+	})
+
+	return series, name, err
+}
+
+func (r *Registry) GetCurrencyList(ctx context.Context) (map[string]string, error) {
+	var currencies map[string]string
+
+	err := r.each(ctx, "GetCurrencyList", func(e entry) error {
+		res, err := e.provider.GetCurrencyList(ctx)
+		if err != nil {
+			return err
+		}
+
+		currencies = res
+		return nil
+	})
+
+	return currencies, err
+}
+
+func (r *Registry) each(ctx context.Context, operation string, call func(e entry) error) error {
+	if len(r.entries) == 0 {
+		return ErrNoProviders
+	}
+
+	errs := make([]error, 0, len(r.entries))
+	failed := false
+	for _, e := range r.entries {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+
+		start := time.Now()
+		err := call(e)
+		if err == nil {
+			metrics.ObserveProviderCall(e.name, operation, start, nil)
+			r.lastSuccess.Store(time.Now().UnixNano())
+			return nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", e.name, err))
+
+		// Провайдер не обращался к API — это не ошибка провайдера ни для метрик, ни для проверки готовности
+		if errors.Is(err, models.ErrProviderThrottled) {
+			r.logger.Warn().Ctx(ctx).Msg(fmt.Sprintf("provider %s is throttled, trying next one: %v", e.name, err))
+			continue
+		}
+
+		metrics.ObserveProviderCall(e.name, operation, start, err)
+		failed = true
+		r.logger.Warn().Ctx(ctx).Msg(fmt.Sprintf("provider %s failed, trying next one: %v", e.name, err))
+	}
+
+	if failed {
+		r.lastFailure.Store(time.Now().UnixNano())
+	}
+
+	return fmt.Errorf("%w: %w", ErrAllFailed, errors.Join(errs...))
+}
+
+// Healthy возвращает ошибку, если последнее обращение к провайдерам завершилось неудачей,
+// а успешного ответа не было дольше maxAge
+func (r *Registry) Healthy(maxAge time.Duration) error {
+	lastSuccess := time.Unix(0, r.lastSuccess.Load())
+	lastFailure := time.Unix(0, r.lastFailure.Load())
+
+	if r.lastSuccess.Load() == 0 {
+		return errors.New("no successful calls to rate providers yet")
+	}
+
+	if lastFailure.After(lastSuccess) && time.Since(lastSuccess) > maxAge {
+		return fmt.Errorf("last successful call to rate providers was %s ago", time.Since(lastSuccess).Round(time.Second))
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/Hashira21/currency-rate/internal/models/config"
+	"github.com/Hashira21/currency-rate/internal/providers/fake"
+	"github.com/Hashira21/currency-rate/internal/providers/frankfurter"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+)
+
+func newFakeRegistry(t *testing.T, quotes ...string) (*Registry, []*fake.Server) {
+	t.Helper()
+
+	registry := New(zerolog.Nop())
+	servers := make([]*fake.Server, 0, len(quotes))
+	for i, quote := range quotes {
+		srv := fake.NewServer(map[string]map[string]decimal.Decimal{
+			"EUR": {"USD": decimal.RequireFromString(quote)},
+		})
+		t.Cleanup(srv.Close)
+
+		cfg := srv.Config(string(rune('a'+i)), i+1)
+		registry.Register(cfg.Name, cfg.Priority, frankfurter.NewProvider(&cfg, zerolog.Nop()))
+		servers = append(servers, srv)
+	}
+
+	return registry, servers
+}
+
+func TestRegistryGetRateFailover(t *testing.T) {
+	tests := []struct {
+		name     string
+		failing  []bool
+		wantName string
+		wantRate string
+		wantErr  error
+	}{
+		{name: "primary answers", failing: []bool{false, false}, wantName: "a", wantRate: "1.1"},
+		{name: "falls back to secondary", failing: []bool{true, false}, wantName: "b", wantRate: "1.2"},
+		{name: "all providers fail", failing: []bool{true, true}, wantErr: ErrAllFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, servers := newFakeRegistry(t, "1.1", "1.2")
+			for i, failing := range tt.failing {
+				servers[i].SetFailing(failing)
+			}
+
+			rates, name, err := registry.GetRate(context.Background(), "USD", "EUR")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetRate() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if name != tt.wantName {
+				t.Errorf("GetRate() provider = %q, want %q", name, tt.wantName)
+			}
+			if want := decimal.RequireFromString(tt.wantRate); !rates.Rates["USD"].Equal(want) {
+				t.Errorf("GetRate() rate = %s, want %s", rates.Rates["USD"], want)
+			}
+		})
+	}
+}
+
+func TestRegistryNoProviders(t *testing.T) {
+	_, _, err := New(zerolog.Nop()).GetRate(context.Background(), "USD", "EUR")
+	if !errors.Is(err, ErrNoProviders) {
+		t.Fatalf("GetRate() error = %v, want %v", err, ErrNoProviders)
+	}
+}
+
+func TestRegistryHealthy(t *testing.T) {
+	registry, servers := newFakeRegistry(t, "1.1")
+
+	if err := registry.Healthy(time.Minute); err == nil {
+		t.Fatal("Healthy() before any call = nil, want error")
+	}
+
+	if _, _, err := registry.GetRate(context.Background(), "USD", "EUR"); err != nil {
+		t.Fatalf("GetRate() error = %v", err)
+	}
+	servers[0].SetFailing(true)
+	if _, _, err := registry.GetRate(context.Background(), "USD", "EUR"); err == nil {
+		t.Fatal("GetRate() with failing provider = nil, want error")
+	}
+
+	if err := registry.Healthy(time.Minute); err != nil {
+		t.Errorf("Healthy() within maxAge = %v, want nil", err)
+	}
+	if err := registry.Healthy(0); err == nil {
+		t.Error("Healthy() past maxAge = nil, want error")
+	}
+}
+
+func TestRegistrySetHost(t *testing.T) {
+	registry, servers := newFakeRegistry(t, "1.1")
+
+	replacement := fake.NewServer(map[string]map[string]decimal.Decimal{"EUR": {"USD": decimal.RequireFromString("1.5")}})
+	defer replacement.Close()
+
+	if err := registry.SetHost("a", replacement.URL); err != nil {
+		t.Fatalf("SetHost() error = %v", err)
+	}
+	servers[0].SetFailing(true)
+
+	rates, _, err := registry.GetRate(context.Background(), "USD", "EUR")
+	if err != nil {
+		t.Fatalf("GetRate() error = %v", err)
+	}
+	if !rates.Rates["USD"].Equal(decimal.RequireFromString("1.5")) {
+		t.Errorf("GetRate() rate = %s, want 1.5", rates.Rates["USD"])
+	}
+
+	if err := registry.SetHost("missing", replacement.URL); err == nil {
+		t.Error("SetHost() for unknown provider = nil, want error")
+	}
+}
+
+func TestRegistryThrottledProvider(t *testing.T) {
+	registry, servers := newFakeRegistry(t, "1.1", "1.2")
+
+	// У первого провайдера бюджет на один запрос, следующий токен появится только через 100 секунд
+	throttled := servers[0].Config("a", 1)
+	throttled.RateLimit = config.Limit{RPS: 0.01, Burst: 1}
+	registry.entries[0].provider = frankfurter.NewProvider(&throttled, zerolog.Nop())
+
+	if _, name, err := registry.GetRate(context.Background(), "USD", "EUR"); err != nil || name != "a" {
+		t.Fatalf("GetRate() = %q, %v, want answer from a", name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if _, name, err := registry.GetRate(ctx, "USD", "EUR"); err != nil || name != "b" {
+		t.Fatalf("GetRate() with throttled primary = %q, %v, want answer from b", name, err)
+	}
+
+	servers[1].SetFailing(true)
+	_, _, err := registry.GetRate(ctx, "USD", "EUR")
+	if !errors.Is(err, ErrAllFailed) || !errors.Is(err, models.ErrProviderThrottled) {
+		t.Fatalf("GetRate() error = %v, want %v wrapping %v", err, ErrAllFailed, models.ErrProviderThrottled)
+	}
+	failedAt := registry.lastFailure.Load()
+	if failedAt == 0 {
+		t.Fatal("failure of b not recorded")
+	}
+
+	// Остался только провайдер без бюджета — это не отказ провайдеров
+	registry.entries = registry.entries[:1]
+	if _, _, err = registry.GetRate(ctx, "USD", "EUR"); !errors.Is(err, models.ErrProviderThrottled) {
+		t.Fatalf("GetRate() error = %v, want %v", err, models.ErrProviderThrottled)
+	}
+	if registry.lastFailure.Load() != failedAt {
+		t.Error("throttled call recorded as provider failure")
+	}
+}
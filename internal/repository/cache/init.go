@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/rs/zerolog"
+)
+
+// cache держит в памяти последний и предыдущий курс пар поверх репозитория. Остальные методы репозитория
+// вызываются напрямую через встроенный интерфейс.
+type cache struct {
+	Postgres
+
+	ttl time.Duration
+
+	mu       sync.Mutex
+	pairs    map[pairKey]*entry
+	listed   []pairKey // пары /all-last в порядке выдачи
+	listedAt time.Time // нулевое время — список нужно перечитать
+	// generation растёт при каждой записи, чтобы не сохранить в кэш данные, прочитанные до неё
+	generation uint64
+
+	logger zerolog.Logger
+}
+
+type pairKey struct {
+	currency string
+	base     string
+}
+
+// entry хранит последний и предыдущий курс пары. Курсы читаются из БД разными запросами, поэтому у каждого
+// своё время загрузки — момент начала чтения, по нему проверяется TTL и выбирается более свежее значение.
+type entry struct {
+	last       *models.CurrencyRateLast // nil — последний курс ещё не загружен
+	lastAt     time.Time
+	prev       *models.CurrencyRateLast
+	prevLoaded bool // prev == nil при prevLoaded означает, что предыдущего курса нет
+	prevAt     time.Time
+}
+
+func New(db Postgres, ttl time.Duration, logger zerolog.Logger) *cache {
+	return &cache{
+		Postgres: db,
+		ttl:      ttl,
+		pairs:    make(map[pairKey]*entry),
+		logger:   logger,
+	}
+}
+
+func (c *cache) fresh(loadedAt, now time.Time) bool {
+	return !loadedAt.IsZero() && now.Sub(loadedAt) < c.ttl
+}
+
+// entry возвращает запись пары, создавая пустую при необходимости. Вызывающий держит c.mu.
+func (c *cache) entry(key pairKey) *entry {
+	e, ok := c.pairs[key]
+	if !ok {
+		e = &entry{}
+		c.pairs[key] = e
+	}
+
+	return e
+}
+
+// setLast сохраняет последний курс, чтение которого началось в readAt, если в памяти нет прочитанного позже
+func (e *entry) setLast(last models.CurrencyRateLast, readAt time.Time) {
+	if e.last != nil && e.lastAt.After(readAt) {
+		return
+	}
+
+	e.last, e.lastAt = &last, readAt
+}
+
+// setPrev — то же для предыдущего курса; nil означает, что предыдущего курса нет
+func (e *entry) setPrev(prev *models.CurrencyRateLast, readAt time.Time) {
+	if e.prevLoaded && e.prevAt.After(readAt) {
+		return
+	}
+
+	e.prev, e.prevLoaded, e.prevAt = prev, true, readAt
+}
+
+// shift делает last последним курсом пары в памяти, а прежний последний — предыдущим. Если last не новее
+// последнего в памяти (в БД есть более поздняя запись, например загруженная из истории), сдвиг был бы неверным,
+// и пара забывается. Пара, которой ещё нет в /all-last, помечает список для перечитывания. Вызывающий держит c.mu.
+func (c *cache) shift(last models.CurrencyRateLast) {
+	key := pairKey{currency: last.Currency, base: last.Base}
+
+	if !slices.Contains(c.listed, key) {
+		c.listedAt = time.Time{}
+	}
+
+	e, ok := c.pairs[key]
+	if !ok {
+		return
+	}
+	if e.last == nil || !last.UpdateDt.After(e.last.UpdateDt) {
+		delete(c.pairs, key)
+		return
+	}
+
+	e.prev, e.prevLoaded, e.prevAt = e.last, true, e.lastAt
+	e.last = &last
+}
+
+// invalidate забывает пары, чьи курсы изменились, и помечает список /all-last для перечитывания.
+// Вызывающий держит c.mu.
+func (c *cache) invalidate(keys ...pairKey) {
+	for _, key := range keys {
+		delete(c.pairs, key)
+	}
+	c.listedAt = time.Time{}
+	c.generation++
+}
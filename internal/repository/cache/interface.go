@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+type Postgres interface {
+	AddToQueue(ctx context.Context, rate models.CurrencyRate, audit models.AuditEntry) error
+	ProcessQueue(ctx context.Context, batchSize, maxAttempts int, backoff func(attempts int32) time.Duration) (models.QueueBatch, error)
+	GetDeadLetters(ctx context.Context, limit int) ([]models.DeadLetter, error)
+	RequeueDeadLetter(ctx context.Context, id string, audit models.AuditEntry) error
+	GetRateStatus(ctx context.Context, id string) (models.RateStatus, error)
+	GetLastRate(ctx context.Context, toIso, fromIso string, excludeManual bool) (models.CurrencyRateLast, error)
+	GetRateAt(ctx context.Context, currency, base string, at time.Time) (models.CurrencyRateWithDt, error)
+	GetPreviousRate(ctx context.Context, currency, base string, excludeManual bool) (models.CurrencyRateLast, error)
+	GetAllLastRates(ctx context.Context, excludeManual bool) ([]models.CurrencyRateLast, error)
+	GetLastRatesAmong(ctx context.Context, isoCodes []string, excludeManual bool) ([]models.CurrencyRateLast, error)
+	GetLatestRates(ctx context.Context) ([]models.LatestRates, error)
+	DeleteByPair(ctx context.Context, currency, base string, audit models.AuditEntry) error
+	UpdateRate(ctx context.Context, currency, base string, rate decimal.Decimal, audit models.AuditEntry) error
+	UpdateRates(ctx context.Context, base string, rates map[string]decimal.Decimal, source string, publishedDt time.Time) (time.Time, error)
+	AddHistoricalRates(ctx context.Context, rates []models.CurrencyRateWithDt, audit models.AuditEntry) (int64, error)
+	GetHistoryRates(ctx context.Context, currency, base string, duration time.Duration, excludeManual bool) ([]models.CurrencyRateWithDt, error)
+	GetPairs(ctx context.Context, status string) ([]models.Pair, error)
+	SavePair(ctx context.Context, pair models.Pair, audit models.AuditEntry) (models.Pair, error)
+	ArchivePair(ctx context.Context, currency, base string, audit models.AuditEntry) (models.Pair, error)
+	TrackPair(ctx context.Context, currency, base string) error
+	GetAuditLog(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
+}
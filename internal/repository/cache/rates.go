@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/infrastructure/metrics"
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	lookupLast     = "last"
+	lookupPrevious = "previous"
+	lookupAllLast  = "all_last"
+)
+
+// GetAllLastRates отдаёт последние курсы из памяти, а при устаревании перечитывает последний
+// и предыдущий курс всех пар одним запросом. Выборка без ручных курсов не кэшируется.
+func (c *cache) GetAllLastRates(ctx context.Context, excludeManual bool) ([]models.CurrencyRateLast, error) {
+	if excludeManual {
+		return c.Postgres.GetAllLastRates(ctx, excludeManual)
+	}
+
+	now := time.Now()
+
+	c.mu.Lock()
+	if rates, ok := c.listedRates(now); ok {
+		c.mu.Unlock()
+
+		metrics.ObserveCache(lookupAllLast, metrics.CacheHit)
+		return rates, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	metrics.ObserveCache(lookupAllLast, metrics.CacheMiss)
+
+	latest, err := c.Postgres.GetLatestRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make([]models.CurrencyRateLast, 0, len(latest))
+	for _, pair := range latest {
+		rates = append(rates, pair.Last)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Пока шёл запрос, курсы поменялись — отдаём прочитанное, но не кэшируем
+	if generation != c.generation {
+		return rates, nil
+	}
+
+	// Курсы, прочитанные отдельными запросами после начала перечитывания, свежее и не перезаписываются
+	c.listed = make([]pairKey, 0, len(latest))
+	for _, pair := range latest {
+		key := pairKey{currency: pair.Last.Currency, base: pair.Last.Base}
+		e := c.entry(key)
+		e.setLast(pair.Last, now)
+		e.setPrev(pair.Previous, now)
+		c.listed = append(c.listed, key)
+	}
+	c.listedAt = now
+
+	c.logger.Debug().Ctx(ctx).Msg(fmt.Sprintf("rate cache reloaded: %d pairs", len(latest)))
+
+	return rates, nil
+}
+
+// listedRates собирает /all-last из памяти. Если список устарел или последний курс какой-то пары
+// не загружен, возвращает false, и список перечитывается. Вызывающий держит c.mu.
+func (c *cache) listedRates(now time.Time) ([]models.CurrencyRateLast, bool) {
+	if !c.fresh(c.listedAt, now) {
+		return nil, false
+	}
+
+	rates := make([]models.CurrencyRateLast, 0, len(c.listed))
+	for _, key := range c.listed {
+		e, ok := c.pairs[key]
+		if !ok || e.last == nil {
+			return nil, false
+		}
+		rates = append(rates, *e.last)
+	}
+
+	return rates, true
+}
+
+// GetLastRate отдаёт последний курс пары из памяти или читает его из БД. Выборка без ручных курсов не кэшируется.
+func (c *cache) GetLastRate(ctx context.Context, toIso, fromIso string, excludeManual bool) (models.CurrencyRateLast, error) {
+	if excludeManual {
+		return c.Postgres.GetLastRate(ctx, toIso, fromIso, excludeManual)
+	}
+
+	key := pairKey{currency: toIso, base: fromIso}
+	now := time.Now()
+
+	c.mu.Lock()
+	if e, ok := c.pairs[key]; ok && e.last != nil && c.fresh(e.lastAt, now) {
+		rate := *e.last
+		c.mu.Unlock()
+
+		metrics.ObserveCache(lookupLast, metrics.CacheHit)
+		return rate, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	metrics.ObserveCache(lookupLast, metrics.CacheMiss)
+
+	rate, err := c.Postgres.GetLastRate(ctx, toIso, fromIso, excludeManual)
+	if err != nil {
+		return rate, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation == c.generation {
+		c.entry(key).setLast(rate, now)
+	}
+
+	return rate, nil
+}
+
+// GetPreviousRate отдаёт предыдущий курс пары из памяти или читает его из БД. Выборка без ручных курсов не кэшируется.
+func (c *cache) GetPreviousRate(ctx context.Context, currency, base string, excludeManual bool) (models.CurrencyRateLast, error) {
+	if excludeManual {
+		return c.Postgres.GetPreviousRate(ctx, currency, base, excludeManual)
+	}
+
+	key := pairKey{currency: currency, base: base}
+	now := time.Now()
+
+	c.mu.Lock()
+	if e, ok := c.pairs[key]; ok && e.prevLoaded && c.fresh(e.prevAt, now) {
+		prev := e.prev
+		c.mu.Unlock()
+
+		metrics.ObserveCache(lookupPrevious, metrics.CacheHit)
+		if prev == nil {
+			return models.CurrencyRateLast{}, pgx.ErrNoRows
+		}
+		return *prev, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	metrics.ObserveCache(lookupPrevious, metrics.CacheMiss)
+
+	rate, err := c.Postgres.GetPreviousRate(ctx, currency, base, excludeManual)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return rate, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation == c.generation {
+		var prev *models.CurrencyRateLast
+		if err == nil {
+			prev = &rate
+		}
+
+		c.entry(key).setPrev(prev, now)
+	}
+
+	return rate, err
+}
@@ -0,0 +1,401 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+)
+
+// store — БД в памяти: курсы пар в порядке добавления
+type store struct {
+	Postgres
+
+	mu    sync.Mutex
+	rates map[pairKey][]models.CurrencyRateLast
+	batch models.QueueBatch
+
+	// held задерживает ответ GetLastRate после чтения, чтобы проверить запись, прошедшую во время запроса
+	held    chan struct{}
+	reading chan struct{}
+
+	lastCalls atomic.Int32
+	prevCalls atomic.Int32
+	listCalls atomic.Int32
+}
+
+func newStore() *store {
+	return &store{rates: make(map[pairKey][]models.CurrencyRateLast)}
+}
+
+func (s *store) add(currency, base, rate string, date time.Time) models.CurrencyRateLast {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := models.CurrencyRateLast{Currency: currency, Base: base, Rate: decimal.RequireFromString(rate), UpdateDt: date}
+	key := pairKey{currency: currency, base: base}
+	s.rates[key] = append(s.rates[key], r)
+	return r
+}
+
+func (s *store) nth(key pairKey, fromEnd int) (models.CurrencyRateLast, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rates := s.rates[key]
+	if len(rates) < fromEnd {
+		return models.CurrencyRateLast{}, pgx.ErrNoRows
+	}
+	return rates[len(rates)-fromEnd], nil
+}
+
+func (s *store) GetLastRate(ctx context.Context, toIso, fromIso string, excludeManual bool) (models.CurrencyRateLast, error) {
+	s.lastCalls.Add(1)
+	rate, err := s.nth(pairKey{currency: toIso, base: fromIso}, 1)
+
+	s.mu.Lock()
+	held, reading := s.held, s.reading
+	s.held = nil
+	s.mu.Unlock()
+
+	if held != nil {
+		reading <- struct{}{}
+		<-held
+	}
+
+	return rate, err
+}
+
+func (s *store) GetPreviousRate(ctx context.Context, currency, base string, excludeManual bool) (models.CurrencyRateLast, error) {
+	s.prevCalls.Add(1)
+	return s.nth(pairKey{currency: currency, base: base}, 2)
+}
+
+func (s *store) GetLatestRates(ctx context.Context) ([]models.LatestRates, error) {
+	s.listCalls.Add(1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latest := make([]models.LatestRates, 0, len(s.rates))
+	for _, rates := range s.rates {
+		pair := models.LatestRates{Last: rates[len(rates)-1]}
+		if len(rates) > 1 {
+			prev := rates[len(rates)-2]
+			pair.Previous = &prev
+		}
+		latest = append(latest, pair)
+	}
+
+	return latest, nil
+}
+
+func (s *store) ProcessQueue(ctx context.Context, batchSize, maxAttempts int,
+	backoff func(attempts int32) time.Duration) (models.QueueBatch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.batch, nil
+}
+
+func (s *store) UpdateRate(ctx context.Context, currency, base string, rate decimal.Decimal, audit models.AuditEntry) error {
+	s.add(currency, base, rate.String(), time.Now())
+	return nil
+}
+
+func (s *store) UpdateRates(ctx context.Context, base string, rates map[string]decimal.Decimal, source string, publishedDt time.Time) (time.Time, error) {
+	updateDt := time.Now()
+	for currency, rate := range rates {
+		s.add(currency, base, rate.String(), updateDt)
+	}
+	return updateDt, nil
+}
+
+var eurUsd = pairKey{currency: "EUR", base: "USD"}
+
+func TestGetAllLastRatesMissingEntry(t *testing.T) {
+	db := newStore()
+	db.add("EUR", "USD", "1.08", time.Now().Add(-time.Hour))
+	c := New(db, time.Minute, zerolog.Nop())
+	ctx := context.Background()
+
+	if _, err := c.GetAllLastRates(ctx, false); err != nil {
+		t.Fatalf("GetAllLastRates() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func()
+	}{
+		{name: "entry removed", corrupt: func() { delete(c.pairs, eurUsd) }},
+		{name: "last not loaded", corrupt: func() { c.pairs[eurUsd].last = nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.GetAllLastRates(ctx, false); err != nil {
+				t.Fatalf("GetAllLastRates() error = %v", err)
+			}
+			calls := db.listCalls.Load()
+
+			c.mu.Lock()
+			tt.corrupt()
+			c.mu.Unlock()
+
+			rates, err := c.GetAllLastRates(ctx, false)
+			if err != nil || len(rates) != 1 {
+				t.Fatalf("GetAllLastRates() = %v, %v", rates, err)
+			}
+			if db.listCalls.Load() != calls+1 {
+				t.Error("incomplete list served from memory instead of reloading")
+			}
+		})
+	}
+}
+
+func TestGetPreviousRateKeepsLast(t *testing.T) {
+	db := newStore()
+	db.add("EUR", "USD", "1.07", time.Now().Add(-2*time.Hour))
+	db.add("EUR", "USD", "1.08", time.Now().Add(-time.Hour))
+	c := New(db, time.Minute, zerolog.Nop())
+	ctx := context.Background()
+
+	if _, err := c.GetLastRate(ctx, "EUR", "USD", false); err != nil {
+		t.Fatalf("GetLastRate() error = %v", err)
+	}
+	prev, err := c.GetPreviousRate(ctx, "EUR", "USD", false)
+	if err != nil || !prev.Rate.Equal(decimal.RequireFromString("1.07")) {
+		t.Fatalf("GetPreviousRate() = %v, %v", prev.Rate, err)
+	}
+
+	last, err := c.GetLastRate(ctx, "EUR", "USD", false)
+	if err != nil || !last.Rate.Equal(decimal.RequireFromString("1.08")) {
+		t.Fatalf("GetLastRate() = %v, %v", last.Rate, err)
+	}
+	if calls := db.lastCalls.Load(); calls != 1 {
+		t.Errorf("last rate read %d times, want previous rate lookup to keep it in memory", calls)
+	}
+
+	if _, err = c.GetPreviousRate(ctx, "EUR", "USD", false); err != nil || db.prevCalls.Load() != 1 {
+		t.Errorf("previous rate read %d times, want 1", db.prevCalls.Load())
+	}
+}
+
+func TestGetLastRateTTL(t *testing.T) {
+	db := newStore()
+	db.add("EUR", "USD", "1.08", time.Now())
+	c := New(db, 20*time.Millisecond, zerolog.Nop())
+	ctx := context.Background()
+
+	c.GetLastRate(ctx, "EUR", "USD", false)
+	c.GetLastRate(ctx, "EUR", "USD", false)
+	if calls := db.lastCalls.Load(); calls != 1 {
+		t.Fatalf("fresh rate read %d times, want 1", calls)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	c.GetLastRate(ctx, "EUR", "USD", false)
+	if calls := db.lastCalls.Load(); calls != 2 {
+		t.Errorf("expired rate read %d times, want 2", calls)
+	}
+}
+
+// startHeldRead запускает GetLastRate, который прочитал БД и ждёт сигнала, чтобы вернуть ответ
+func startHeldRead(t *testing.T, c *cache, db *store) (release func() models.CurrencyRateLast) {
+	t.Helper()
+
+	held := make(chan struct{})
+	db.mu.Lock()
+	db.held, db.reading = held, make(chan struct{})
+	db.mu.Unlock()
+
+	result := make(chan models.CurrencyRateLast)
+	go func() {
+		rate, _ := c.GetLastRate(context.Background(), "EUR", "USD", false)
+		result <- rate
+	}()
+	<-db.reading
+
+	return func() models.CurrencyRateLast {
+		close(held)
+		return <-result
+	}
+}
+
+func TestGetLastRateConcurrentReload(t *testing.T) {
+	db := newStore()
+	db.add("EUR", "USD", "1.08", time.Now().Add(-time.Hour))
+	c := New(db, time.Minute, zerolog.Nop())
+
+	release := startHeldRead(t, c, db)
+
+	// Курс поменяла другая реплика, а перечитывание списка закончилось раньше медленного запроса
+	time.Sleep(time.Millisecond)
+	db.add("EUR", "USD", "1.09", time.Now())
+	if _, err := c.GetAllLastRates(context.Background(), false); err != nil {
+		t.Fatalf("GetAllLastRates() error = %v", err)
+	}
+
+	if stale := release(); !stale.Rate.Equal(decimal.RequireFromString("1.08")) {
+		t.Fatalf("held read returned %s, want the rate it read", stale.Rate)
+	}
+
+	last, _ := c.GetLastRate(context.Background(), "EUR", "USD", false)
+	if !last.Rate.Equal(decimal.RequireFromString("1.09")) {
+		t.Errorf("GetLastRate() = %s, older read overwrote the reload", last.Rate)
+	}
+	if calls := db.lastCalls.Load(); calls != 1 {
+		t.Errorf("last rate read %d times, want the reloaded value served from memory", calls)
+	}
+}
+
+func TestGetLastRateConcurrentWrite(t *testing.T) {
+	db := newStore()
+	db.add("EUR", "USD", "1.08", time.Now().Add(-time.Hour))
+	c := New(db, time.Minute, zerolog.Nop())
+
+	release := startHeldRead(t, c, db)
+
+	if err := c.UpdateRate(context.Background(), "EUR", "USD", decimal.RequireFromString("1.1"), models.AuditEntry{}); err != nil {
+		t.Fatalf("UpdateRate() error = %v", err)
+	}
+	release()
+
+	last, _ := c.GetLastRate(context.Background(), "EUR", "USD", false)
+	if !last.Rate.Equal(decimal.RequireFromString("1.1")) {
+		t.Errorf("GetLastRate() = %s, read started before the write was cached", last.Rate)
+	}
+}
+
+func TestProcessQueueShift(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		appliedDt time.Time
+		wantLast  string
+		wantPrev  string
+		wantReads int32
+	}{
+		{name: "newer rate shifts", appliedDt: now, wantLast: "1.09", wantPrev: "1.08", wantReads: 1},
+		{name: "older rate invalidates", appliedDt: now.Add(-2 * time.Hour), wantLast: "1.08", wantPrev: "1.09", wantReads: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newStore()
+			db.add("EUR", "USD", "1.08", now.Add(-time.Hour))
+			c := New(db, time.Minute, zerolog.Nop())
+			ctx := context.Background()
+
+			if _, err := c.GetAllLastRates(ctx, false); err != nil {
+				t.Fatalf("GetAllLastRates() error = %v", err)
+			}
+
+			applied := db.add("EUR", "USD", "1.09", tt.appliedDt)
+			if tt.appliedDt.Before(now.Add(-time.Hour)) {
+				// Строка очереди применилась раньше курса, который уже есть в БД: в выдаче он должен остаться последним
+				db.mu.Lock()
+				db.rates[eurUsd][0], db.rates[eurUsd][1] = db.rates[eurUsd][1], db.rates[eurUsd][0]
+				db.mu.Unlock()
+			}
+			db.batch = models.QueueBatch{Applied: []models.CurrencyRateWithDt{{
+				Currency: applied.Currency, Base: applied.Base, Rate: applied.Rate, UpdateDt: applied.UpdateDt,
+			}}}
+			if _, err := c.ProcessQueue(ctx, 10, 5, nil); err != nil {
+				t.Fatalf("ProcessQueue() error = %v", err)
+			}
+
+			last, _ := c.GetLastRate(ctx, "EUR", "USD", false)
+			prev, _ := c.GetPreviousRate(ctx, "EUR", "USD", false)
+			if !last.Rate.Equal(decimal.RequireFromString(tt.wantLast)) || !prev.Rate.Equal(decimal.RequireFromString(tt.wantPrev)) {
+				t.Errorf("last, previous = %s, %s, want %s, %s", last.Rate, prev.Rate, tt.wantLast, tt.wantPrev)
+			}
+			if reads := db.lastCalls.Load() + db.listCalls.Load(); reads != tt.wantReads {
+				t.Errorf("database read %d times, want %d", reads, tt.wantReads)
+			}
+		})
+	}
+}
+
+func TestUpdateRatesKeepsAllLastWarm(t *testing.T) {
+	db := newStore()
+	db.add("EUR", "USD", "1.08", time.Now().Add(-time.Hour))
+	db.add("GBP", "USD", "1.27", time.Now().Add(-time.Hour))
+	c := New(db, time.Minute, zerolog.Nop())
+	ctx := context.Background()
+
+	if _, err := c.GetAllLastRates(ctx, false); err != nil {
+		t.Fatalf("GetAllLastRates() error = %v", err)
+	}
+
+	rates := map[string]decimal.Decimal{"EUR": decimal.RequireFromString("1.09")}
+	if _, err := c.UpdateRates(ctx, "USD", rates, "frankfurter", time.Now().Truncate(24*time.Hour)); err != nil {
+		t.Fatalf("UpdateRates() error = %v", err)
+	}
+
+	all, err := c.GetAllLastRates(ctx, false)
+	if err != nil {
+		t.Fatalf("GetAllLastRates() error = %v", err)
+	}
+	if calls := db.listCalls.Load(); calls != 1 {
+		t.Errorf("all-last reloaded %d times, want served from memory after the update", calls)
+	}
+	for _, rate := range all {
+		if rate.Currency == "EUR" && (!rate.Rate.Equal(decimal.RequireFromString("1.09")) || rate.Source != "frankfurter") {
+			t.Errorf("EUR/USD = %s from %q, want 1.09 from frankfurter", rate.Rate, rate.Source)
+		}
+	}
+
+	prev, err := c.GetPreviousRate(ctx, "EUR", "USD", false)
+	if err != nil || !prev.Rate.Equal(decimal.RequireFromString("1.08")) {
+		t.Errorf("GetPreviousRate() = %s, %v, want the replaced 1.08", prev.Rate, err)
+	}
+	if calls := db.prevCalls.Load(); calls != 0 {
+		t.Errorf("previous rate read from the database %d times, want shifted in memory", calls)
+	}
+}
+
+func TestCacheConcurrentAccess(t *testing.T) {
+	db := newStore()
+	db.add("EUR", "USD", "1.08", time.Now().Add(-time.Hour))
+	db.add("GBP", "USD", "1.27", time.Now().Add(-time.Hour))
+	c := New(db, time.Millisecond, zerolog.Nop())
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for worker := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range 200 {
+				switch (worker + i) % 4 {
+				case 0:
+					if _, err := c.GetAllLastRates(ctx, false); err != nil {
+						t.Errorf("GetAllLastRates() error = %v", err)
+					}
+				case 1:
+					c.GetLastRate(ctx, "EUR", "USD", false)
+				case 2:
+					c.GetPreviousRate(ctx, "GBP", "USD", false)
+				case 3:
+					c.UpdateRate(ctx, "EUR", "USD", decimal.NewFromInt(int64(i)), models.AuditEntry{})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	time.Sleep(2 * time.Millisecond)
+	want, _ := db.nth(eurUsd, 1)
+	if last, _ := c.GetLastRate(ctx, "EUR", "USD", false); !last.Rate.Equal(want.Rate) {
+		t.Errorf("GetLastRate() = %s after concurrent writes, want %s", last.Rate, want.Rate)
+	}
+}
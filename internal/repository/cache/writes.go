@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+// ProcessQueue применяет пачку очереди и сдвигает курсы применённых пар в памяти
+func (c *cache) ProcessQueue(ctx context.Context, batchSize, maxAttempts int,
+	backoff func(attempts int32) time.Duration) (models.QueueBatch, error) {
+	batch, err := c.Postgres.ProcessQueue(ctx, batchSize, maxAttempts, backoff)
+	if err != nil || len(batch.Applied) == 0 {
+		return batch, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+
+	for _, rate := range batch.Applied {
+		c.shift(models.CurrencyRateLast{
+			Currency:    rate.Currency,
+			Base:        rate.Base,
+			Rate:        rate.Rate,
+			UpdateDt:    rate.UpdateDt,
+			Source:      rate.Source,
+			PublishedDt: rate.PublishedDt,
+		})
+	}
+
+	return batch, nil
+}
+
+func (c *cache) UpdateRate(ctx context.Context, currency, base string, rate decimal.Decimal, audit models.AuditEntry) error {
+	if err := c.Postgres.UpdateRate(ctx, currency, base, rate, audit); err != nil {
+		return err
+	}
+
+	c.forget(pairKey{currency: currency, base: base})
+	return nil
+}
+
+// UpdateRates сдвигает курсы обновлённых пар в памяти, не сбрасывая список /all-last:
+// автообновление пишет курсы постоянно, и полная инвалидация не давала бы кэшу прогреться
+func (c *cache) UpdateRates(ctx context.Context, base string, rates map[string]decimal.Decimal, source string, publishedDt time.Time) (time.Time, error) {
+	updateDt, err := c.Postgres.UpdateRates(ctx, base, rates, source, publishedDt)
+	if err != nil {
+		return updateDt, err
+	}
+
+	var published *time.Time
+	if !publishedDt.IsZero() {
+		published = &publishedDt
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+
+	for currency, rate := range rates {
+		c.shift(models.CurrencyRateLast{
+			Currency:    currency,
+			Base:        base,
+			Rate:        rate,
+			UpdateDt:    updateDt,
+			Source:      source,
+			PublishedDt: published,
+		})
+	}
+
+	return updateDt, nil
+}
+
+// AddHistoricalRates может добавить курс новее последнего в памяти, если пара давно не обновлялась
+func (c *cache) AddHistoricalRates(ctx context.Context, rates []models.CurrencyRateWithDt, audit models.AuditEntry) (int64, error) {
+	inserted, err := c.Postgres.AddHistoricalRates(ctx, rates, audit)
+	if err != nil || inserted == 0 {
+		return inserted, err
+	}
+
+	keys := make([]pairKey, 0, len(rates))
+	for _, rate := range rates {
+		keys = append(keys, pairKey{currency: rate.Currency, base: rate.Base})
+	}
+
+	c.forget(keys...)
+	return inserted, nil
+}
+
+func (c *cache) DeleteByPair(ctx context.Context, currency, base string, audit models.AuditEntry) error {
+	if err := c.Postgres.DeleteByPair(ctx, currency, base, audit); err != nil {
+		return err
+	}
+
+	c.forget(pairKey{currency: currency, base: base})
+	return nil
+}
+
+// SavePair, ArchivePair и TrackPair меняют состав /all-last: архивные пары в нём не показываются
+
+func (c *cache) SavePair(ctx context.Context, pair models.Pair, audit models.AuditEntry) (models.Pair, error) {
+	saved, err := c.Postgres.SavePair(ctx, pair, audit)
+	if err != nil {
+		return saved, err
+	}
+
+	c.forget(pairKey{currency: pair.Currency, base: pair.Base})
+	return saved, nil
+}
+
+func (c *cache) ArchivePair(ctx context.Context, currency, base string, audit models.AuditEntry) (models.Pair, error) {
+	archived, err := c.Postgres.ArchivePair(ctx, currency, base, audit)
+	if err != nil {
+		return archived, err
+	}
+
+	c.forget(pairKey{currency: currency, base: base})
+	return archived, nil
+}
+
+func (c *cache) TrackPair(ctx context.Context, currency, base string) error {
+	if err := c.Postgres.TrackPair(ctx, currency, base); err != nil {
+		return err
+	}
+
+	c.forget(pairKey{currency: currency, base: base})
+	return nil
+}
+
+func (c *cache) forget(keys ...pairKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invalidate(keys...)
+}
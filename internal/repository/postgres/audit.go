@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// writeAudit записывает изменение в журнал в той же транзакции, что и само изменение,
+// чтобы данные не могли поменяться без следа
+func (db *database) writeAudit(ctx context.Context, tx pgx.Tx, entry models.AuditEntry) error {
+	_, err := tx.Exec(ctx, `
+        INSERT INTO plata_currency_rates.audit_log (actor, action, currency, base, old_value, new_value, request_id)
+        VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, NULLIF($7, ''));
+    `, entry.Actor, entry.Action, entry.Currency, entry.Base, jsonOrNull(entry.OldValue), jsonOrNull(entry.NewValue), entry.RequestId)
+	if err != nil {
+		return fmt.Errorf("write audit entry %s: %w", entry.Action, err)
+	}
+
+	return nil
+}
+
+// auditValue выполняет запрос, возвращающий jsonb со значением для журнала; nil, если строки нет
+func (db *database) auditValue(ctx context.Context, tx pgx.Tx, query string, args ...any) ([]byte, error) {
+	var value []byte
+
+	err := tx.QueryRow(ctx, query, args...).Scan(&value)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	return value, err
+}
+
+// auditJSON сериализует значение для журнала; используется для карт из простых типов, ошибки быть не может
+func auditJSON(value any) []byte {
+	data, _ := json.Marshal(value)
+	return data
+}
+
+// mergeAuditValue дописывает поля к JSON-объекту, подготовленному сервисом
+func mergeAuditValue(value []byte, fields map[string]any) ([]byte, error) {
+	merged := make(map[string]any, len(fields))
+	if len(value) > 0 {
+		if err := json.Unmarshal(value, &merged); err != nil {
+			return nil, fmt.Errorf("audit value: %w", err)
+		}
+	}
+
+	for name, field := range fields {
+		merged[name] = field
+	}
+
+	return auditJSON(merged), nil
+}
+
+func jsonOrNull(value []byte) any {
+	if len(value) == 0 {
+		return nil
+	}
+
+	return string(value)
+}
+
+// lastRateValue — последний курс пары в виде значения для журнала
+const lastRateValue = `
+        SELECT jsonb_build_object('rate', rate, 'date', date, 'source', source)
+        FROM plata_currency_rates.rates
+        WHERE currency = $1 AND base = $2
+        ORDER BY date DESC
+        LIMIT 1
+    `
+
+// pairValue — состояние пары в реестре в виде значения для журнала
+const pairValue = `
+        SELECT jsonb_build_object('status', status, 'intervalSec', interval_sec)
+        FROM plata_currency_rates.pairs
+        WHERE currency = $1 AND base = $2
+    `
+
+// GetAuditLog возвращает записи журнала по фильтру, новые первыми
+func (db *database) GetAuditLog(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	childCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	var from, to *time.Time
+	if !filter.From.IsZero() {
+		from = &filter.From
+	}
+	if !filter.To.IsZero() {
+		to = &filter.To
+	}
+
+	rows, err := conn.Query(childCtx, `
+        SELECT id, actor, action, COALESCE(currency, ''), COALESCE(base, ''),
+               old_value, new_value, COALESCE(request_id, ''), created_dt
+        FROM plata_currency_rates.audit_log
+        WHERE ($1 = '' OR currency = $1)
+          AND ($2 = '' OR base = $2)
+          AND ($3 = '' OR actor = $3)
+          AND ($4::timestamp IS NULL OR created_dt >= $4)
+          AND ($5::timestamp IS NULL OR created_dt <= $5)
+        ORDER BY created_dt DESC, id DESC
+        LIMIT $6;
+    `, filter.Currency, filter.Base, filter.Actor, from, to, filter.Limit)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+
+	for rows.Next() {
+		var entry models.AuditEntry
+		var oldValue, newValue []byte
+		err := rows.Scan(&entry.Id, &entry.Actor, &entry.Action, &entry.Currency, &entry.Base,
+			&oldValue, &newValue, &entry.RequestId, &entry.CreatedDt)
+		if err != nil {
+			db.logger.Error().Ctx(ctx).Msg(err.Error())
+			return nil, err
+		}
+		entry.OldValue, entry.NewValue = oldValue, newValue
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergeAuditValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name:  "service fields kept",
+			value: `{"from":"2024-01-01","to":"2024-01-31","provider":"frankfurter"}`,
+			want: map[string]any{
+				"from": "2024-01-01", "to": "2024-01-31", "provider": "frankfurter",
+				"received": float64(22), "inserted": float64(20),
+			},
+		},
+		{
+			name: "empty value",
+			want: map[string]any{"received": float64(22), "inserted": float64(20)},
+		},
+		{
+			name:  "summary overrides",
+			value: `{"inserted":0}`,
+			want:  map[string]any{"received": float64(22), "inserted": float64(20)},
+		},
+		{
+			name:    "not an object",
+			value:   `[1, 2]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := mergeAuditValue([]byte(tt.value), map[string]any{"received": 22, "inserted": int64(20)})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("mergeAuditValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var got map[string]any
+			if err = json.Unmarshal(merged, &got); err != nil {
+				t.Fatalf("merged value %s: %v", merged, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeAuditValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
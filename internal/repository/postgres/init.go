@@ -1,22 +1,49 @@
 package postgres
 
 import (
+	"context"
+	"fmt"
 	"time"
 
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog"
 )
 
-const timeout = 10 * time.Second
+const (
+	timeout = 10 * time.Second
+
+	defaultAcquireTimeout = 3 * time.Second
+)
 
 type database struct {
-	conn   *pgx.Conn
-	logger zerolog.Logger
+	pool           *pgxpool.Pool
+	acquireTimeout time.Duration
+	logger         zerolog.Logger
 }
 
-func New(conn *pgx.Conn, logger zerolog.Logger) *database {
+func New(pool *pgxpool.Pool, acquireTimeout time.Duration, logger zerolog.Logger) *database {
+	if acquireTimeout <= 0 {
+		acquireTimeout = defaultAcquireTimeout
+	}
+
 	return &database{
-		conn:   conn,
-		logger: logger,
+		pool:           pool,
+		acquireTimeout: acquireTimeout,
+		logger:         logger,
 	}
 }
+
+// acquire берёт соединение из пула, ожидая свободное не дольше acquireTimeout
+func (db *database) acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	acquireCtx, cancel := context.WithTimeout(ctx, db.acquireTimeout)
+	defer cancel()
+
+	conn, err := db.pool.Acquire(acquireCtx)
+	if err != nil {
+		err = fmt.Errorf("acquire connection from pool: %w", err)
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return nil, err
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsPrefix = "currency_rate_db_pool_"
+
+// poolCollector отдаёт статистику пула соединений в /metrics на момент скрейпа
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	totalConns              *prometheus.Desc
+	idleConns               *prometheus.Desc
+	acquiredConns           *prometheus.Desc
+	constructingConns       *prometheus.Desc
+	maxConns                *prometheus.Desc
+	acquireCount            *prometheus.Desc
+	acquireDuration         *prometheus.Desc
+	canceledAcquireCount    *prometheus.Desc
+	emptyAcquireCount       *prometheus.Desc
+	newConnsCount           *prometheus.Desc
+	maxLifetimeDestroyCount *prometheus.Desc
+	maxIdleDestroyCount     *prometheus.Desc
+}
+
+func NewPoolCollector(pool *pgxpool.Pool) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(metricsPrefix+name, help, nil, nil)
+	}
+
+	return &poolCollector{
+		pool:                    pool,
+		totalConns:              desc("total_conns", "Total number of connections currently in the pool."),
+		idleConns:               desc("idle_conns", "Number of idle connections in the pool."),
+		acquiredConns:           desc("acquired_conns", "Number of currently acquired connections."),
+		constructingConns:       desc("constructing_conns", "Number of connections being established."),
+		maxConns:                desc("max_conns", "Maximum size of the pool."),
+		acquireCount:            desc("acquire_total", "Cumulative count of successful acquires."),
+		acquireDuration:         desc("acquire_duration_seconds_total", "Total time spent waiting for successful acquires."),
+		canceledAcquireCount:    desc("canceled_acquire_total", "Cumulative count of acquires canceled by context."),
+		emptyAcquireCount:       desc("empty_acquire_total", "Cumulative count of acquires that waited for a free connection."),
+		newConnsCount:           desc("new_conns_total", "Cumulative count of new connections opened."),
+		maxLifetimeDestroyCount: desc("max_lifetime_destroy_total", "Cumulative count of connections closed due to MaxConnLifetime."),
+		maxIdleDestroyCount:     desc("max_idle_destroy_total", "Cumulative count of connections closed due to MaxConnIdleTime."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+
+	gauge(c.totalConns, float64(stat.TotalConns()))
+	gauge(c.idleConns, float64(stat.IdleConns()))
+	gauge(c.acquiredConns, float64(stat.AcquiredConns()))
+	gauge(c.constructingConns, float64(stat.ConstructingConns()))
+	gauge(c.maxConns, float64(stat.MaxConns()))
+	counter(c.acquireCount, float64(stat.AcquireCount()))
+	counter(c.acquireDuration, stat.AcquireDuration().Seconds())
+	counter(c.canceledAcquireCount, float64(stat.CanceledAcquireCount()))
+	counter(c.emptyAcquireCount, float64(stat.EmptyAcquireCount()))
+	counter(c.newConnsCount, float64(stat.NewConnsCount()))
+	counter(c.maxLifetimeDestroyCount, float64(stat.MaxLifetimeDestroyCount()))
+	counter(c.maxIdleDestroyCount, float64(stat.MaxIdleDestroyCount()))
+}
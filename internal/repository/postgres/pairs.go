@@ -0,0 +1,196 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const pairColumns = `currency, base, status, COALESCE(interval_sec, 0), created_dt, update_dt`
+
+// GetPairs возвращает пары реестра; пустой status — все пары, включая архивные
+func (db *database) GetPairs(ctx context.Context, status string) ([]models.Pair, error) {
+	childCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(childCtx,
+		`SELECT `+pairColumns+`
+		 FROM plata_currency_rates.pairs
+		 WHERE $1 = '' OR status = $1
+		 ORDER BY currency, base`, status)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pairs []models.Pair
+
+	for rows.Next() {
+		var pair models.Pair
+		err := rows.Scan(&pair.Currency, &pair.Base, &pair.Status, &pair.IntervalSec, &pair.CreatedDt, &pair.UpdateDt)
+		if err != nil {
+			db.logger.Error().Ctx(ctx).Msg(err.Error())
+			return nil, err
+		}
+		pairs = append(pairs, pair)
+	}
+
+	if err = rows.Err(); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return nil, err
+	}
+
+	return pairs, nil
+}
+
+// SavePair добавляет пару в реестр или меняет статус и интервал уже существующей
+func (db *database) SavePair(ctx context.Context, pair models.Pair, audit models.AuditEntry) (models.Pair, error) {
+	childCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return models.Pair{}, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(childCtx)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.Pair{}, err
+	}
+
+	defer tx.Rollback(childCtx)
+
+	if audit.OldValue, err = db.auditValue(childCtx, tx, pairValue, pair.Currency, pair.Base); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.Pair{}, err
+	}
+
+	var saved models.Pair
+
+	err = tx.QueryRow(childCtx, `
+        INSERT INTO plata_currency_rates.pairs (currency, base, status, interval_sec, update_dt)
+        VALUES ($1, $2, $3, NULLIF($4, 0), current_timestamp)
+        ON CONFLICT (currency, base) DO UPDATE
+        SET status = EXCLUDED.status, interval_sec = EXCLUDED.interval_sec, update_dt = EXCLUDED.update_dt
+        RETURNING `+pairColumns+`;
+    `, pair.Currency, pair.Base, pair.Status, pair.IntervalSec).
+		Scan(&saved.Currency, &saved.Base, &saved.Status, &saved.IntervalSec, &saved.CreatedDt, &saved.UpdateDt)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.Pair{}, err
+	}
+
+	audit.NewValue = auditJSON(map[string]any{"status": saved.Status, "intervalSec": nullableInterval(saved.IntervalSec)})
+	if err = db.writeAudit(childCtx, tx, audit); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.Pair{}, err
+	}
+
+	if err = tx.Commit(childCtx); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.Pair{}, err
+	}
+
+	return saved, nil
+}
+
+// ArchivePair переводит пару в архив, история курсов остаётся в БД
+func (db *database) ArchivePair(ctx context.Context, currency, base string, audit models.AuditEntry) (models.Pair, error) {
+	childCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return models.Pair{}, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(childCtx)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.Pair{}, err
+	}
+
+	defer tx.Rollback(childCtx)
+
+	if audit.OldValue, err = db.auditValue(childCtx, tx, pairValue, currency, base); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.Pair{}, err
+	}
+
+	var archived models.Pair
+
+	err = tx.QueryRow(childCtx, `
+        UPDATE plata_currency_rates.pairs
+        SET status = 'archived', update_dt = current_timestamp
+        WHERE currency = $1 AND base = $2
+        RETURNING `+pairColumns+`;
+    `, currency, base).
+		Scan(&archived.Currency, &archived.Base, &archived.Status, &archived.IntervalSec, &archived.CreatedDt, &archived.UpdateDt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			db.logger.Warn().Ctx(ctx).Msg(fmt.Sprintf("pair %s/%s is not registered", currency, base))
+			return models.Pair{}, err
+		}
+
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.Pair{}, err
+	}
+
+	audit.NewValue = auditJSON(map[string]any{"status": archived.Status, "intervalSec": nullableInterval(archived.IntervalSec)})
+	if err = db.writeAudit(childCtx, tx, audit); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.Pair{}, err
+	}
+
+	if err = tx.Commit(childCtx); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.Pair{}, err
+	}
+
+	return archived, nil
+}
+
+// nullableInterval приводит интервал пары к виду, в котором он хранится: NULL — интервал по умолчанию
+func nullableInterval(intervalSec int32) any {
+	if intervalSec == 0 {
+		return nil
+	}
+
+	return intervalSec
+}
+
+// TrackPair добавляет пару в реестр активной, не трогая уже зарегистрированные (в том числе приостановленные и архивные)
+func (db *database) TrackPair(ctx context.Context, currency, base string) error {
+	childCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(childCtx, `
+        INSERT INTO plata_currency_rates.pairs (currency, base)
+        VALUES ($1, $2)
+        ON CONFLICT DO NOTHING;
+    `, currency, base)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+	}
+
+	return err
+}
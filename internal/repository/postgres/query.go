@@ -8,148 +8,207 @@ import (
 
 	"github.com/Hashira21/currency-rate/internal/models"
 	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
 )
 
-func (db *database) AddToQueue(ctx context.Context, rate models.CurrencyRate) error {
+// AddToQueue ставит курс в очередь и записывает в журнал, кто его запросил
+func (db *database) AddToQueue(ctx context.Context, rate models.CurrencyRate, audit models.AuditEntry) error {
 	childCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	_, err := db.conn.Exec(childCtx,
-		`SELECT * FROM plata_currency_rates.add_to_queue(_id := $1, _currency := $2, _base := $3, _rate := $4)`,
-		rate.Id, rate.Currency, rate.Base, rate.Rate)
+	conn, err := db.acquire(childCtx)
 	if err != nil {
-		db.logger.Error().Msg(err.Error())
 		return err
 	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(childCtx)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return err
+	}
+
+	defer tx.Rollback(childCtx)
+
+	_, err = tx.Exec(childCtx,
+		`SELECT * FROM plata_currency_rates.add_to_queue(_id := $1, _currency := $2, _base := $3, _rate := $4,
+		 _source := $5, _published_dt := $6)`,
+		rate.Id, rate.Currency, rate.Base, rate.Rate, rate.Provider, nullTime(rate.PublishedDt))
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return err
+	}
+
+	if audit.OldValue, err = db.auditValue(childCtx, tx, lastRateValue, rate.Currency, rate.Base); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return err
+	}
+	audit.NewValue = auditJSON(map[string]any{"id": rate.Id, "rate": rate.Rate, "source": rate.Provider})
+
+	if err = db.writeAudit(childCtx, tx, audit); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return err
+	}
+
+	if err = tx.Commit(childCtx); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+	}
 
 	return err
 }
 
-func (db *database) ConfirmQueue(ctx context.Context) (models.CurrencyRateWithDt, error) {
+// GetLastRate возвращает последний курс пары; при excludeManual ручные установки курса пропускаются
+func (db *database) GetLastRate(ctx context.Context, toIso, fromIso string, excludeManual bool) (models.CurrencyRateLast, error) {
 	childCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	var currRate models.CurrencyRateDto
-	var rate models.CurrencyRateWithDtDto
-
-	tx, err := db.conn.Begin(childCtx)
+	conn, err := db.acquire(childCtx)
 	if err != nil {
-		db.logger.Error().Msg(err.Error())
-		return models.CurrencyRateWithDt{}, err
+		return models.CurrencyRateLast{}, err
 	}
+	defer conn.Release()
 
-	defer tx.Rollback(childCtx)
-
-	err = tx.QueryRow(childCtx,
-		`SELECT * FROM plata_currency_rates.confirm_queue();`).
-		Scan(&currRate.Id, &currRate.Currency, &currRate.Base, &currRate.Rate)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			db.logger.Warn().Msg("queue is empty")
-			return models.CurrencyRateWithDt{}, err
-		}
-
-		db.logger.Error().Msg(err.Error())
-		return models.CurrencyRateWithDt{}, err
-	}
+	var rate models.CurrencyRateWithDtDto
 
-	err = tx.QueryRow(childCtx,
-		`SELECT * FROM plata_currency_rates.add_to_rates(_id := $1, _currency := $2, _base := $3, _rate := $4);`,
-		currRate.Id, currRate.Currency, currRate.Base, currRate.Rate,
-	).Scan(&rate.Id, &rate.Currency, &rate.Base, &rate.Rate, &rate.UpdateDt)
+	err = conn.QueryRow(childCtx,
+		`SELECT currency, base, rate, date, source, published_dt FROM plata_currency_rates.rates
+         WHERE currency = $1 AND base = $2 AND NOT ($3 AND source = 'manual')
+         ORDER BY date DESC LIMIT 1;`,
+		toIso, fromIso, excludeManual).
+		Scan(&rate.Currency, &rate.Base, &rate.Rate, &rate.UpdateDt, &rate.Source, &rate.PublishedDt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			db.logger.Warn().Msg(err.Error())
-			return models.CurrencyRateWithDt{}, err
+			db.logger.Warn().Ctx(ctx).Msg(err.Error())
+			return models.CurrencyRateLast{}, err
 		}
 
-		db.logger.Error().Msg(err.Error())
-		return models.CurrencyRateWithDt{}, err
-	}
-
-	result, err := rate.FromDto()
-	if err != nil {
-		db.logger.Error().Msg(err.Error())
-		return models.CurrencyRateWithDt{}, err
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.CurrencyRateLast{}, err
 	}
 
-	err = tx.Commit(childCtx)
+	result, err := rate.FromDtoToLast()
 	if err != nil {
-		db.logger.Error().Msg(err.Error())
-		return models.CurrencyRateWithDt{}, err
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.CurrencyRateLast{}, err
 	}
 
 	return result, err
 }
 
-func (db *database) GetById(ctx context.Context, id string) (models.CurrencyRateWithDt, error) {
+// GetRateAt возвращает курс, действовавший в момент at: последнюю запись не позже этого момента, если она покрывает
+// день at. Запись покрывает день, если сделана в этот день или если её публикация была последней на этот день:
+// есть запись с той же датой публикации, сделанная в этот день или позже. Иначе между записью и at мог выйти
+// курс, которого в БД нет, и возвращается pgx.ErrNoRows.
+func (db *database) GetRateAt(ctx context.Context, currency, base string, at time.Time) (models.CurrencyRateWithDt, error) {
 	childCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return models.CurrencyRateWithDt{}, err
+	}
+	defer conn.Release()
+
 	var rate models.CurrencyRateWithDtDto
 
-	err := db.conn.QueryRow(childCtx,
-		`SELECT * FROM plata_currency_rates.get_by_id(_id := $1);`,
-		id).
-		Scan(&rate.Id, &rate.Currency, &rate.Base, &rate.Rate, &rate.UpdateDt)
+	err = conn.QueryRow(childCtx,
+		`SELECT r.id, r.currency, r.base, r.rate, r.date, r.source, r.published_dt
+         FROM (
+             SELECT id, currency, base, rate, date, source, published_dt FROM plata_currency_rates.rates
+             WHERE currency = $1 AND base = $2 AND date <= $3::timestamp
+             ORDER BY date DESC LIMIT 1
+         ) r
+         WHERE r.date::date = $3::timestamp::date
+            OR EXISTS (
+                SELECT 1 FROM plata_currency_rates.rates n
+                WHERE n.currency = r.currency AND n.base = r.base
+                  AND n.date >= $3::timestamp::date
+                  AND n.published_dt::date = COALESCE(r.published_dt, r.date)::date
+            );`,
+		currency, base, at).
+		Scan(&rate.Id, &rate.Currency, &rate.Base, &rate.Rate, &rate.UpdateDt, &rate.Source, &rate.PublishedDt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			db.logger.Warn().Msg(err.Error())
+			db.logger.Warn().Ctx(ctx).Msg(fmt.Sprintf("Нет курса %s/%s на %s", currency, base, at))
 			return models.CurrencyRateWithDt{}, err
 		}
 
-		db.logger.Error().Msg(err.Error())
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
 		return models.CurrencyRateWithDt{}, err
 	}
 
 	result, err := rate.FromDto()
 	if err != nil {
-		db.logger.Error().Msg(err.Error())
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
 		return models.CurrencyRateWithDt{}, err
 	}
 
-	return result, err
+	return result, nil
 }
 
-func (db *database) GetLastRate(ctx context.Context, toIso, fromIso string) (models.CurrencyRateLast, error) {
+// GetAllLastRates возвращает последние курсы неархивных пар; при excludeManual ручные установки курса пропускаются
+func (db *database) GetAllLastRates(ctx context.Context, excludeManual bool) ([]models.CurrencyRateLast, error) {
 	childCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	var rate models.CurrencyRateWithDtDto
-
-	err := db.conn.QueryRow(childCtx,
-		`SELECT * FROM plata_currency_rates.get_last_rate(_currency := $1, _base := $2);`,
-		toIso, fromIso).
-		Scan(&rate.Currency, &rate.Base, &rate.Rate, &rate.UpdateDt)
+	conn, err := db.acquire(childCtx)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			db.logger.Warn().Msg(err.Error())
-			return models.CurrencyRateLast{}, err
-		}
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(childCtx,
+		`SELECT DISTINCT ON (r.currency, r.base) r.currency, r.base, r.rate, r.date, r.source, r.published_dt
+		 FROM plata_currency_rates.rates r
+		 JOIN plata_currency_rates.pairs p ON p.currency = r.currency AND p.base = r.base
+		 WHERE p.status <> 'archived' AND NOT ($1 AND r.source = 'manual')
+		 ORDER BY r.currency, r.base, r.date DESC`, excludeManual)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
 
-		db.logger.Error().Msg(err.Error())
-		return models.CurrencyRateLast{}, err
+	var rates []models.CurrencyRateLast
+
+	for rows.Next() {
+		var rate models.CurrencyRateLast
+		err := rows.Scan(&rate.Currency, &rate.Base, &rate.Rate, &rate.UpdateDt, &rate.Source, &rate.PublishedDt)
+		if err != nil {
+			db.logger.Error().Ctx(ctx).Msg(err.Error())
+			return nil, err
+		}
+		rates = append(rates, rate)
 	}
 
-	result, err := rate.FromDtoToLast()
-	if err != nil {
-		db.logger.Error().Msg(err.Error())
-		return models.CurrencyRateLast{}, err
+	if err = rows.Err(); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return nil, err
 	}
 
-	return result, err
+	return rates, nil
 }
 
-func (db *database) GetAllLastRates(ctx context.Context) ([]models.CurrencyRateLast, error) {
+// GetLastRatesAmong возвращает последние курсы неархивных пар, обе валюты которых входят в isoCodes
+func (db *database) GetLastRatesAmong(ctx context.Context, isoCodes []string, excludeManual bool) ([]models.CurrencyRateLast, error) {
 	childCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	rows, err := db.conn.Query(childCtx,
-		`SELECT DISTINCT ON (currency, base) currency, base, rate, date 
-		 FROM plata_currency_rates.rates 
-		 ORDER BY currency, base, date DESC`)
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(childCtx,
+		`SELECT DISTINCT ON (r.currency, r.base) r.currency, r.base, r.rate, r.date, r.source, r.published_dt
+		 FROM plata_currency_rates.rates r
+		 JOIN plata_currency_rates.pairs p ON p.currency = r.currency AND p.base = r.base
+		 WHERE p.status <> 'archived' AND NOT ($1 AND r.source = 'manual')
+		   AND r.currency = ANY($2) AND r.base = ANY($2)
+		 ORDER BY r.currency, r.base, r.date DESC`, excludeManual, isoCodes)
 	if err != nil {
-		db.logger.Error().Msg(err.Error())
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
 		return nil, err
 	}
 	defer rows.Close()
@@ -158,66 +217,323 @@ func (db *database) GetAllLastRates(ctx context.Context) ([]models.CurrencyRateL
 
 	for rows.Next() {
 		var rate models.CurrencyRateLast
-		err := rows.Scan(&rate.Currency, &rate.Base, &rate.Rate, &rate.UpdateDt)
+		err := rows.Scan(&rate.Currency, &rate.Base, &rate.Rate, &rate.UpdateDt, &rate.Source, &rate.PublishedDt)
 		if err != nil {
-			db.logger.Error().Msg(err.Error())
+			db.logger.Error().Ctx(ctx).Msg(err.Error())
 			return nil, err
 		}
 		rates = append(rates, rate)
 	}
 
 	if err = rows.Err(); err != nil {
-		db.logger.Error().Msg(err.Error())
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
 		return nil, err
 	}
 
 	return rates, nil
 }
 
-func (db *database) DeleteByPair(ctx context.Context, currency, base string) error {
+// GetLatestRates одним запросом возвращает последний и предыдущий курс каждой неархивной пары
+func (db *database) GetLatestRates(ctx context.Context) ([]models.LatestRates, error) {
+	childCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(childCtx,
+		`SELECT p.currency, p.base, r.rate, r.date, r.source, r.published_dt, r.rn
+		 FROM plata_currency_rates.pairs p
+		 CROSS JOIN LATERAL (
+		     SELECT rate, date, source, published_dt, row_number() OVER (ORDER BY date DESC) AS rn
+		     FROM plata_currency_rates.rates
+		     WHERE currency = p.currency AND base = p.base
+		     ORDER BY date DESC
+		     LIMIT 2
+		 ) r
+		 WHERE p.status <> 'archived'
+		 ORDER BY p.currency, p.base, r.rn`)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var latest []models.LatestRates
+
+	for rows.Next() {
+		var rate models.CurrencyRateLast
+		var rn int64
+		err := rows.Scan(&rate.Currency, &rate.Base, &rate.Rate, &rate.UpdateDt, &rate.Source, &rate.PublishedDt, &rn)
+		if err != nil {
+			db.logger.Error().Ctx(ctx).Msg(err.Error())
+			return nil, err
+		}
+
+		// Строки пары идут подряд: сначала последний курс, затем предыдущий
+		if rn == 1 {
+			latest = append(latest, models.LatestRates{Last: rate})
+		} else {
+			latest[len(latest)-1].Previous = &rate
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return nil, err
+	}
+
+	return latest, nil
+}
+
+func (db *database) DeleteByPair(ctx context.Context, currency, base string, audit models.AuditEntry) error {
 	childCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	_, err := db.conn.Exec(childCtx, `
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(childCtx)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return err
+	}
+
+	defer tx.Rollback(childCtx)
+
+	// В журнал попадает последний курс, размер удаляемой истории и состояние пары в реестре
+	audit.OldValue, err = db.auditValue(childCtx, tx, `
+        SELECT jsonb_build_object(
+            'rate', (`+lastRateValue+`)->'rate',
+            'rates', (SELECT count(*) FROM plata_currency_rates.rates WHERE currency = $1 AND base = $2),
+            'pair', (`+pairValue+`)
+        )
+    `, currency, base)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return err
+	}
+
+	_, err = tx.Exec(childCtx, `
         DELETE FROM plata_currency_rates.rates 
         WHERE currency = $1 AND base = $2
     `, currency, base)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return err
+	}
 
+	// Вместе с историей пара удаляется из реестра; чтобы сохранить историю, пару нужно архивировать
+	_, err = tx.Exec(childCtx, `
+        DELETE FROM plata_currency_rates.pairs
+        WHERE currency = $1 AND base = $2
+    `, currency, base)
 	if err != nil {
-		db.logger.Error().Msg(err.Error())
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return err
+	}
+
+	if err = db.writeAudit(childCtx, tx, audit); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return err
+	}
+
+	if err = tx.Commit(childCtx); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
 	}
 
 	return err
 }
 
-func (db *database) UpdateRate(ctx context.Context, currency, base string, newRate float64) error {
+func (db *database) UpdateRate(ctx context.Context, currency, base string, newRate decimal.Decimal, audit models.AuditEntry) error {
 	childCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(childCtx)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return err
+	}
+
+	defer tx.Rollback(childCtx)
+
+	if audit.OldValue, err = db.auditValue(childCtx, tx, lastRateValue, currency, base); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return err
+	}
+
 	query := `
-        INSERT INTO plata_currency_rates.rates (id, currency, base, rate, date)
-        VALUES (gen_random_uuid(), $1, $2, $3, NOW());
+        INSERT INTO plata_currency_rates.rates (id, currency, base, rate, date, source)
+        VALUES (gen_random_uuid(), $1, $2, $3, NOW(), 'manual')
+        RETURNING jsonb_build_object('rate', rate, 'date', date, 'source', source);
     `
 
-	_, err := db.conn.Exec(childCtx, query, currency, base, newRate)
+	err = tx.QueryRow(childCtx, query, currency, base, newRate).Scan(&audit.NewValue)
 	if err != nil {
-		db.logger.Error().Msg(fmt.Sprintf("Ошибка добавления нового курса %s/%s: %v", currency, base, err))
+		db.logger.Error().Ctx(ctx).Msg(fmt.Sprintf("Ошибка добавления нового курса %s/%s: %v", currency, base, err))
+		return err
+	}
+
+	if err = db.writeAudit(childCtx, tx, audit); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
 		return err
 	}
 
-	db.logger.Info().Msg(fmt.Sprintf("Новый курс %s/%s успешно добавлен: %f", currency, base, newRate))
+	if err = tx.Commit(childCtx); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return err
+	}
+
+	db.logger.Info().Ctx(ctx).Msg(fmt.Sprintf("Новый курс %s/%s успешно добавлен: %s", currency, base, newRate))
 	return nil
 }
 
+// UpdateRates сохраняет котировки нескольких валют к одной базе в одной транзакции и возвращает дату записи курсов.
+// source — имя провайдера, publishedDt — дата публикации котировок у него.
+func (db *database) UpdateRates(ctx context.Context, base string, rates map[string]decimal.Decimal, source string, publishedDt time.Time) (time.Time, error) {
+	childCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(childCtx)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return time.Time{}, err
+	}
+
+	defer tx.Rollback(childCtx)
+
+	// NOW() постоянен в пределах транзакции: это дата всех добавленных ниже курсов
+	var updateDt time.Time
+	if err = tx.QueryRow(childCtx, `SELECT NOW()::timestamp;`).Scan(&updateDt); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return time.Time{}, err
+	}
+
+	batch := &pgx.Batch{}
+	for currency, rate := range rates {
+		batch.Queue(`
+        INSERT INTO plata_currency_rates.rates (id, currency, base, rate, date, source, published_dt)
+        VALUES (gen_random_uuid(), $1, $2, $3, NOW(), $4, $5);
+    `, currency, base, rate, source, nullTime(publishedDt))
+	}
+
+	if err = tx.SendBatch(childCtx, batch).Close(); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(fmt.Sprintf("Ошибка добавления курсов к %s: %v", base, err))
+		return time.Time{}, err
+	}
+
+	if err = tx.Commit(childCtx); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return time.Time{}, err
+	}
+
+	db.logger.Info().Ctx(ctx).Msg(fmt.Sprintf("Добавлено %d новых курсов к %s", len(rates), base))
+	return updateDt, nil
+}
+
+// AddHistoricalRates сохраняет курсы с их историческими датами, пропуская дни, за которые курс пары уже есть.
+// Возвращает количество добавленных записей. Запись журнала дополняется итогом загрузки (received, inserted)
+// и пишется в той же транзакции.
+func (db *database) AddHistoricalRates(ctx context.Context, rates []models.CurrencyRateWithDt, audit models.AuditEntry) (int64, error) {
+	childCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(childCtx)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return 0, err
+	}
+
+	defer tx.Rollback(childCtx)
+
+	batch := &pgx.Batch{}
+	for _, rate := range rates {
+		batch.Queue(`
+        INSERT INTO plata_currency_rates.rates (id, currency, base, rate, date, source, published_dt)
+        SELECT gen_random_uuid(), $1, $2, $3, $4, $5, $6
+        WHERE NOT EXISTS (
+            SELECT 1 FROM plata_currency_rates.rates
+            WHERE currency = $1 AND base = $2 AND date >= $4::date AND date < $4::date + 1
+        );
+    `, rate.Currency, rate.Base, rate.Rate, rate.UpdateDt, rate.Source, rate.PublishedDt)
+	}
+
+	results := tx.SendBatch(childCtx, batch)
+
+	var inserted int64
+	for range rates {
+		tag, err := results.Exec()
+		if err != nil {
+			results.Close()
+			db.logger.Error().Ctx(ctx).Msg(err.Error())
+			return 0, err
+		}
+		inserted += tag.RowsAffected()
+	}
+
+	if err = results.Close(); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return 0, err
+	}
+
+	audit.NewValue, err = mergeAuditValue(audit.NewValue, map[string]any{"received": len(rates), "inserted": inserted})
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return 0, err
+	}
+
+	if err = db.writeAudit(childCtx, tx, audit); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return 0, err
+	}
+
+	if err = tx.Commit(childCtx); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return 0, err
+	}
+
+	return inserted, nil
+}
+
 func (db *database) GetLastRateWithChange(ctx context.Context, toIso, fromIso string) (models.CurrencyRateWithChange, error) {
 	childCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return models.CurrencyRateWithChange{}, err
+	}
+	defer conn.Release()
+
 	var rate models.CurrencyRateWithDtDto
 	var prevRate models.CurrencyRateWithDtDto
 
 	// Получаем последний курс
-	err := db.conn.QueryRow(childCtx,
+	err = conn.QueryRow(childCtx,
 		`SELECT id, currency, base, rate, date FROM plata_currency_rates.rates
          WHERE currency = $1 AND base = $2 ORDER BY date DESC LIMIT 1;`,
 		toIso, fromIso).
@@ -227,73 +543,87 @@ func (db *database) GetLastRateWithChange(ctx context.Context, toIso, fromIso st
 	}
 
 	// Получаем предыдущий курс
-	err = db.conn.QueryRow(childCtx,
+	err = conn.QueryRow(childCtx,
 		`SELECT rate FROM plata_currency_rates.rates
          WHERE currency = $1 AND base = $2 ORDER BY date DESC OFFSET 1 LIMIT 1;`,
 		toIso, fromIso).
 		Scan(&prevRate.Rate)
 	if err != nil {
-		prevRate.Rate.Float64 = rate.Rate.Float64 // Если предыдущего курса нет, берём тот же
+		prevRate.Rate = rate.Rate // Если предыдущего курса нет, берём тот же
 	}
 
 	// Вычисляем процентное изменение
-	changePct := ((rate.Rate.Float64 - prevRate.Rate.Float64) / prevRate.Rate.Float64) * 100
+	changePct := models.ChangePct(rate.Rate.Decimal, prevRate.Rate.Decimal)
 
 	return models.CurrencyRateWithChange{
 		Id:        rate.Id.String,
 		Currency:  rate.Currency.String,
 		Base:      rate.Base.String,
-		Rate:      rate.Rate.Float64,
+		Rate:      rate.Rate.Decimal,
 		UpdateDt:  rate.UpdateDt.Time,
 		ChangePct: changePct,
 	}, nil
 }
 
-func (db *database) GetPreviousRate(ctx context.Context, currency, base string) (models.CurrencyRateLast, error) {
+func (db *database) GetPreviousRate(ctx context.Context, currency, base string, excludeManual bool) (models.CurrencyRateLast, error) {
 	childCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return models.CurrencyRateLast{}, err
+	}
+	defer conn.Release()
+
 	var prevRate models.CurrencyRateWithDtDto
 
-	err := db.conn.QueryRow(childCtx,
-		`SELECT id, currency, base, rate, date FROM plata_currency_rates.rates 
-         WHERE currency = $1 AND base = $2 
+	err = conn.QueryRow(childCtx,
+		`SELECT id, currency, base, rate, date, source, published_dt FROM plata_currency_rates.rates 
+         WHERE currency = $1 AND base = $2 AND NOT ($3 AND source = 'manual')
          ORDER BY date DESC OFFSET 1 LIMIT 1;`,
-		currency, base).
-		Scan(&prevRate.Id, &prevRate.Currency, &prevRate.Base, &prevRate.Rate, &prevRate.UpdateDt)
+		currency, base, excludeManual).
+		Scan(&prevRate.Id, &prevRate.Currency, &prevRate.Base, &prevRate.Rate, &prevRate.UpdateDt, &prevRate.Source, &prevRate.PublishedDt)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			db.logger.Warn().Msg(fmt.Sprintf("Нет предыдущего курса для %s/%s", currency, base))
+			db.logger.Warn().Ctx(ctx).Msg(fmt.Sprintf("Нет предыдущего курса для %s/%s", currency, base))
 			return models.CurrencyRateLast{}, err
 		}
-		db.logger.Error().Msg(err.Error())
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
 		return models.CurrencyRateLast{}, err
 	}
 
 	result, err := prevRate.FromDtoToLast()
 	if err != nil {
-		db.logger.Error().Msg(err.Error())
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
 		return models.CurrencyRateLast{}, err
 	}
 
 	return result, nil
 }
 
-func (db *database) GetHistoryRates(ctx context.Context, currency, base string, duration time.Duration) ([]models.CurrencyRateWithDt, error) {
+// GetHistoryRates возвращает курсы пары за последние duration; при excludeManual ручные установки курса пропускаются
+func (db *database) GetHistoryRates(ctx context.Context, currency, base string, duration time.Duration, excludeManual bool) ([]models.CurrencyRateWithDt, error) {
 	childCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	rows, err := db.conn.Query(childCtx,
-		`SELECT id, currency, base, rate, date 
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(childCtx,
+		`SELECT id, currency, base, rate, date, source, published_dt 
          FROM plata_currency_rates.rates 
          WHERE currency = $1 AND base = $2 
          AND date >= NOW() - $3::INTERVAL 
+         AND NOT ($4 AND source = 'manual')
          ORDER BY date ASC`, // ASC для правильного порядка на графике
-		currency, base, fmt.Sprintf("%d minutes", int(duration.Minutes())))
+		currency, base, fmt.Sprintf("%d minutes", int(duration.Minutes())), excludeManual)
 
 	if err != nil {
-		db.logger.Error().Msg(err.Error())
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
 		return nil, err
 	}
 	defer rows.Close()
@@ -307,6 +637,8 @@ func (db *database) GetHistoryRates(ctx context.Context, currency, base string,
 			&rateDto.Base,
 			&rateDto.Rate,
 			&rateDto.UpdateDt,
+			&rateDto.Source,
+			&rateDto.PublishedDt,
 		); err != nil {
 			return nil, err
 		}
@@ -320,3 +652,33 @@ func (db *database) GetHistoryRates(ctx context.Context, currency, base string,
 
 	return rates, nil
 }
+
+// QueueSize возвращает количество курсов, ожидающих подтверждения в очереди
+func (db *database) QueueSize(ctx context.Context) (int64, error) {
+	childCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	var size int64
+	err = conn.QueryRow(childCtx, `SELECT count(*) FROM plata_currency_rates.rates_queue;`).Scan(&size)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// nullTime передаёт нулевое время как NULL
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
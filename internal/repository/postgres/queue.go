@@ -0,0 +1,324 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+type queueRow struct {
+	id          string
+	currency    string
+	base        string
+	rate        decimal.Decimal
+	enqueueDt   time.Time
+	attempts    int32
+	source      string
+	publishedDt *time.Time
+}
+
+// На каждую строку пачки приходится до пяти запросов (точка сохранения, вставка курса, регистрация пары,
+// удаление из очереди, фиксация точки), поэтому таймаут транзакции растёт вместе с размером пачки
+const queueRowTimeout = 100 * time.Millisecond
+
+func queueBatchTimeout(batchSize int) time.Duration {
+	return timeout + time.Duration(batchSize)*queueRowTimeout
+}
+
+// ProcessQueue применяет до batchSize строк очереди, готовых к обработке. Строки блокируются через
+// FOR UPDATE SKIP LOCKED, поэтому несколько реплик разбирают очередь параллельно, не мешая друг другу.
+// Строка, которую не удалось применить, откладывается на backoff(attempts), а после maxAttempts попыток
+// переносится в rates_dead_letter.
+func (db *database) ProcessQueue(ctx context.Context, batchSize, maxAttempts int,
+	backoff func(attempts int32) time.Duration) (models.QueueBatch, error) {
+	childCtx, cancel := context.WithTimeout(ctx, queueBatchTimeout(batchSize))
+	defer cancel()
+
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return models.QueueBatch{}, err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(childCtx)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.QueueBatch{}, err
+	}
+
+	defer tx.Rollback(childCtx)
+
+	rows, err := tx.Query(childCtx, `
+        SELECT id, currency, base, rate, date, attempts, source, published_dt
+        FROM plata_currency_rates.rates_queue
+        WHERE next_attempt_dt <= current_timestamp
+        ORDER BY date
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED;
+    `, batchSize)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.QueueBatch{}, err
+	}
+
+	queued, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (queueRow, error) {
+		var r queueRow
+		err := row.Scan(&r.id, &r.currency, &r.base, &r.rate, &r.enqueueDt, &r.attempts, &r.source, &r.publishedDt)
+		return r, err
+	})
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.QueueBatch{}, err
+	}
+
+	var batch models.QueueBatch
+
+	for _, row := range queued {
+		applied, applyErr := db.applyQueueRow(childCtx, tx, row)
+		if applyErr == nil {
+			batch.Applied = append(batch.Applied, applied)
+			continue
+		}
+
+		// Если истёк таймаут или оборвалось соединение, транзакция уже непригодна — откатываем пачку целиком,
+		// попытки при этом не засчитываются
+		if childCtx.Err() != nil {
+			return models.QueueBatch{}, childCtx.Err()
+		}
+
+		attempts, exhausted := nextAttempt(row.attempts, maxAttempts)
+		if exhausted {
+			err = db.deadLetter(childCtx, tx, row, attempts, applyErr)
+			batch.DeadLettered++
+		} else {
+			err = db.retryLater(childCtx, tx, row, attempts, backoff(attempts), applyErr)
+			batch.Retried++
+		}
+		if err != nil {
+			db.logger.Error().Ctx(ctx).Msg(err.Error())
+			return models.QueueBatch{}, err
+		}
+	}
+
+	if err = tx.Commit(childCtx); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.QueueBatch{}, err
+	}
+
+	return batch, nil
+}
+
+// nextAttempt засчитывает неудачную попытку и сообщает, исчерпаны ли попытки и строку пора переносить в dead-letter
+func nextAttempt(attempts int32, maxAttempts int) (int32, bool) {
+	attempts++
+	return attempts, int(attempts) >= maxAttempts
+}
+
+// applyQueueRow переносит строку очереди в rates в отдельной точке сохранения, чтобы ошибка одной строки
+// не откатывала остальную пачку
+func (db *database) applyQueueRow(ctx context.Context, tx pgx.Tx, row queueRow) (models.CurrencyRateWithDt, error) {
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return models.CurrencyRateWithDt{}, err
+	}
+
+	defer savepoint.Rollback(ctx)
+
+	var rate models.CurrencyRateWithDt
+
+	err = savepoint.QueryRow(ctx, `
+        INSERT INTO plata_currency_rates.rates (id, currency, base, rate, date, source, published_dt)
+        VALUES ($1, $2, $3, $4, current_timestamp, $5, $6)
+        RETURNING id, currency, base, rate, date, source, published_dt;
+    `, row.id, row.currency, row.base, row.rate, row.source, row.publishedDt).
+		Scan(&rate.Id, &rate.Currency, &rate.Base, &rate.Rate, &rate.UpdateDt, &rate.Source, &rate.PublishedDt)
+	if err != nil {
+		db.logger.Warn().Ctx(ctx).Msg(fmt.Sprintf("failed to apply queued rate %s: %v", row.id, err))
+		return models.CurrencyRateWithDt{}, err
+	}
+
+	_, err = savepoint.Exec(ctx, `
+        INSERT INTO plata_currency_rates.pairs (currency, base)
+        VALUES ($1, $2)
+        ON CONFLICT DO NOTHING;
+    `, row.currency, row.base)
+	if err != nil {
+		return models.CurrencyRateWithDt{}, err
+	}
+
+	_, err = savepoint.Exec(ctx, `DELETE FROM plata_currency_rates.rates_queue WHERE id = $1;`, row.id)
+	if err != nil {
+		return models.CurrencyRateWithDt{}, err
+	}
+
+	return rate, savepoint.Commit(ctx)
+}
+
+func (db *database) retryLater(ctx context.Context, tx pgx.Tx, row queueRow, attempts int32, delay time.Duration, cause error) error {
+	_, err := tx.Exec(ctx, `
+        UPDATE plata_currency_rates.rates_queue
+        SET attempts = $2,
+            next_attempt_dt = current_timestamp + make_interval(secs => $3),
+            last_error = $4
+        WHERE id = $1;
+    `, row.id, attempts, delay.Seconds(), cause.Error())
+
+	return err
+}
+
+func (db *database) deadLetter(ctx context.Context, tx pgx.Tx, row queueRow, attempts int32, cause error) error {
+	_, err := tx.Exec(ctx, `
+        WITH moved AS (
+            DELETE FROM plata_currency_rates.rates_queue WHERE id = $1
+            RETURNING id, currency, base, rate, date, source, published_dt
+        )
+        INSERT INTO plata_currency_rates.rates_dead_letter (id, currency, base, rate, date, source, published_dt, attempts, last_error)
+        SELECT id, currency, base, rate, date, source, published_dt, $2, $3 FROM moved
+        ON CONFLICT (id) DO UPDATE
+        SET attempts = EXCLUDED.attempts, last_error = EXCLUDED.last_error, failed_dt = current_timestamp;
+    `, row.id, attempts, cause.Error())
+	if err != nil {
+		return err
+	}
+
+	db.logger.Error().Ctx(ctx).Msg(fmt.Sprintf("queued rate %s %s/%s moved to dead-letter after %d attempts: %v",
+		row.id, row.currency, row.base, attempts, cause))
+
+	return nil
+}
+
+func (db *database) GetDeadLetters(ctx context.Context, limit int) ([]models.DeadLetter, error) {
+	childCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	rows, err := conn.Query(childCtx, `
+        SELECT id, currency, base, rate, source, date, attempts, last_error, failed_dt
+        FROM plata_currency_rates.rates_dead_letter
+        ORDER BY failed_dt DESC
+        LIMIT $1;
+    `, limit)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return nil, err
+	}
+
+	letters, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.DeadLetter, error) {
+		var l models.DeadLetter
+		err := row.Scan(&l.Id, &l.Currency, &l.Base, &l.Rate, &l.Source, &l.EnqueueDt, &l.Attempts, &l.LastError, &l.FailedDt)
+		return l, err
+	})
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return nil, err
+	}
+
+	return letters, nil
+}
+
+// RequeueDeadLetter возвращает строку из dead-letter в очередь со сброшенным счётчиком попыток
+func (db *database) RequeueDeadLetter(ctx context.Context, id string, audit models.AuditEntry) error {
+	childCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	tx, err := conn.Begin(childCtx)
+	if err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return err
+	}
+
+	defer tx.Rollback(childCtx)
+
+	err = tx.QueryRow(childCtx, `
+        WITH moved AS (
+            DELETE FROM plata_currency_rates.rates_dead_letter WHERE id = $1
+            RETURNING id, currency, base, rate, date, source, published_dt, attempts, last_error
+        ), requeued AS (
+            INSERT INTO plata_currency_rates.rates_queue (id, currency, base, rate, date, source, published_dt)
+            SELECT id, currency, base, rate, date, source, published_dt FROM moved
+        )
+        SELECT currency, base, jsonb_build_object('id', id, 'rate', rate, 'attempts', attempts, 'lastError', last_error)
+        FROM moved;
+    `, id).Scan(&audit.Currency, &audit.Base, &audit.OldValue)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			db.logger.Warn().Ctx(ctx).Msg(fmt.Sprintf("dead-letter %s not found", id))
+			return err
+		}
+
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return err
+	}
+
+	audit.NewValue = auditJSON(map[string]any{"id": id, "attempts": 0})
+	if err = db.writeAudit(childCtx, tx, audit); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return err
+	}
+
+	if err = tx.Commit(childCtx); err != nil {
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+	}
+
+	return err
+}
+
+// GetRateStatus ищет запрос на обновление курса в rates, очереди и dead-letter.
+// Для строки в очереди считает позицию: количество строк, поставленных раньше, плюс один.
+func (db *database) GetRateStatus(ctx context.Context, id string) (models.RateStatus, error) {
+	childCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := db.acquire(childCtx)
+	if err != nil {
+		return models.RateStatus{}, err
+	}
+	defer conn.Release()
+
+	status := models.RateStatus{Id: id}
+	var rate decimal.Decimal
+
+	err = conn.QueryRow(childCtx, `
+        SELECT 'applied', currency, base, rate, date, NULL::timestamp, 0::bigint, 0, NULL::timestamp, NULL::text
+        FROM plata_currency_rates.rates WHERE id = $1
+        UNION ALL
+        SELECT 'queued', q.currency, q.base, q.rate, NULL, q.date,
+               (SELECT count(*) + 1 FROM plata_currency_rates.rates_queue ahead
+                WHERE ahead.date < q.date OR (ahead.date = q.date AND ahead.id < q.id)),
+               q.attempts, q.next_attempt_dt, q.last_error
+        FROM plata_currency_rates.rates_queue q WHERE q.id = $1
+        UNION ALL
+        SELECT 'failed', currency, base, rate, NULL, date, 0, attempts, NULL, last_error
+        FROM plata_currency_rates.rates_dead_letter WHERE id = $1
+        LIMIT 1;
+    `, id).Scan(&status.Status, &status.Currency, &status.Base, &rate, &status.UpdateDt, &status.EnqueueDt,
+		&status.QueuePosition, &status.Attempts, &status.NextAttemptDt, &status.LastError)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.RateStatus{}, err
+		}
+
+		db.logger.Error().Ctx(ctx).Msg(err.Error())
+		return models.RateStatus{}, err
+	}
+
+	status.Rate = &rate
+
+	return status, nil
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import "testing"
+
+func TestNextAttempt(t *testing.T) {
+	tests := []struct {
+		attempts      int32
+		maxAttempts   int
+		wantAttempts  int32
+		wantExhausted bool
+	}{
+		{attempts: 0, maxAttempts: 5, wantAttempts: 1},
+		{attempts: 3, maxAttempts: 5, wantAttempts: 4},
+		{attempts: 4, maxAttempts: 5, wantAttempts: 5, wantExhausted: true},
+		{attempts: 0, maxAttempts: 1, wantAttempts: 1, wantExhausted: true},
+		// Лимит уменьшили в конфигурации, пока строка ждала повтора
+		{attempts: 7, maxAttempts: 5, wantAttempts: 8, wantExhausted: true},
+	}
+
+	for _, tt := range tests {
+		attempts, exhausted := nextAttempt(tt.attempts, tt.maxAttempts)
+		if attempts != tt.wantAttempts || exhausted != tt.wantExhausted {
+			t.Errorf("nextAttempt(%d, %d) = %d, %t, want %d, %t",
+				tt.attempts, tt.maxAttempts, attempts, exhausted, tt.wantAttempts, tt.wantExhausted)
+		}
+	}
+}
+
+func TestQueueBatchTimeout(t *testing.T) {
+	if got := queueBatchTimeout(100); got <= timeout {
+		t.Errorf("queueBatchTimeout(100) = %s, want more than the default %s", got, timeout)
+	}
+	if queueBatchTimeout(1000) <= queueBatchTimeout(100) {
+		t.Error("queueBatchTimeout does not grow with the batch size")
+	}
+}
@@ -1,15 +1,19 @@
 package router
 
 import (
+	"github.com/Hashira21/currency-rate/internal/infrastructure/metrics"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/requestid"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/tracing"
 	"github.com/gorilla/mux"
 )
 
-func NewRouter(ctr Controller) *mux.Router {
+func NewRouter(ctr Controller, authn Authenticator, limiter RateLimiter) *mux.Router {
 	router := mux.NewRouter()
 	router.StrictSlash(true)
+	router.Use(metrics.Middleware, tracing.Middleware, requestid.Middleware)
 
 	techRouter(router)
-	setRoutes(router, ctr)
+	setRoutes(router, ctr, authn, limiter)
 
 	return router
 }
@@ -1,13 +1,35 @@
 package router
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/Hashira21/currency-rate/internal/infrastructure/auth"
+)
 
 type Controller interface {
 	UpdateRate(w http.ResponseWriter, r *http.Request)
 	GetById(w http.ResponseWriter, r *http.Request)
 	GetLastRate(w http.ResponseWriter, r *http.Request)
+	GetRateAt(w http.ResponseWriter, r *http.Request)
+	Convert(w http.ResponseWriter, r *http.Request)
 	GetAllLastRates(w http.ResponseWriter, r *http.Request)
 	DeleteByPair(w http.ResponseWriter, r *http.Request)
 	UpdateCurrencyRate(w http.ResponseWriter, r *http.Request)
 	GetHistory(w http.ResponseWriter, r *http.Request)
+	Backfill(w http.ResponseWriter, r *http.Request)
+	GetPairs(w http.ResponseWriter, r *http.Request)
+	SavePair(w http.ResponseWriter, r *http.Request)
+	ArchivePair(w http.ResponseWriter, r *http.Request)
+	GetDeadLetters(w http.ResponseWriter, r *http.Request)
+	RequeueDeadLetter(w http.ResponseWriter, r *http.Request)
+	GetAuditLog(w http.ResponseWriter, r *http.Request)
+}
+
+type Authenticator interface {
+	Require(role auth.Role, next http.Handler) http.Handler
+}
+
+type RateLimiter interface {
+	Limit(route string, next http.Handler) http.Handler
+	LimitUnauthorized(route string, next http.Handler) http.Handler
 }
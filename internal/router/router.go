@@ -3,6 +3,7 @@ package router
 import (
 	"net/http"
 
+	"github.com/Hashira21/currency-rate/internal/infrastructure/auth"
 	"github.com/Hashira21/currency-rate/internal/infrastructure/tech"
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -16,18 +17,28 @@ type route struct {
 	method  string
 	path    string
 	name    string
+	role    auth.Role // минимальная роль для вызова
 	handler http.HandlerFunc
 }
 
-func setRoutes(router *mux.Router, c Controller) {
+func setRoutes(router *mux.Router, c Controller, a Authenticator, l RateLimiter) {
 	var routes = []route{
-		{method: http.MethodDelete, path: "/delete/{currency}/{base}", name: "DeleteByPair", handler: c.DeleteByPair},
-		{method: http.MethodPut, path: "", name: "UpdateRate", handler: c.UpdateRate},
-		{method: http.MethodGet, path: "/by-id/{id}", name: "GetById", handler: c.GetById},
-		{method: http.MethodGet, path: "/last", name: "GetLastRate", handler: c.GetLastRate},
-		{method: http.MethodGet, path: "/all-last", name: "GetAllLastRates", handler: c.GetAllLastRates},
-		{method: http.MethodPatch, path: "/update", name: "UpdateCurrencyRate", handler: c.UpdateCurrencyRate},
-		{method: http.MethodGet, path: "/history", name: "GetHistory", handler: c.GetHistory},
+		{method: http.MethodDelete, path: "/delete/{currency}/{base}", name: "DeleteByPair", role: auth.RoleAdmin, handler: c.DeleteByPair},
+		{method: http.MethodPut, path: "", name: "UpdateRate", role: auth.RoleOperator, handler: c.UpdateRate},
+		{method: http.MethodGet, path: "/by-id/{id}", name: "GetById", role: auth.RoleReader, handler: c.GetById},
+		{method: http.MethodGet, path: "/last", name: "GetLastRate", role: auth.RoleReader, handler: c.GetLastRate},
+		{method: http.MethodGet, path: "/at", name: "GetRateAt", role: auth.RoleReader, handler: c.GetRateAt},
+		{method: http.MethodGet, path: "/convert", name: "Convert", role: auth.RoleReader, handler: c.Convert},
+		{method: http.MethodGet, path: "/all-last", name: "GetAllLastRates", role: auth.RoleReader, handler: c.GetAllLastRates},
+		{method: http.MethodPatch, path: "/update", name: "UpdateCurrencyRate", role: auth.RoleOperator, handler: c.UpdateCurrencyRate},
+		{method: http.MethodGet, path: "/history", name: "GetHistory", role: auth.RoleReader, handler: c.GetHistory},
+		{method: http.MethodPost, path: "/backfill", name: "Backfill", role: auth.RoleOperator, handler: c.Backfill},
+		{method: http.MethodGet, path: "/pairs", name: "GetPairs", role: auth.RoleReader, handler: c.GetPairs},
+		{method: http.MethodPost, path: "/pairs", name: "SavePair", role: auth.RoleOperator, handler: c.SavePair},
+		{method: http.MethodDelete, path: "/pairs/{currency}/{base}", name: "ArchivePair", role: auth.RoleOperator, handler: c.ArchivePair},
+		{method: http.MethodGet, path: "/admin/dead-letter", name: "GetDeadLetters", role: auth.RoleAdmin, handler: c.GetDeadLetters},
+		{method: http.MethodPost, path: "/admin/dead-letter/{id}/requeue", name: "RequeueDeadLetter", role: auth.RoleAdmin, handler: c.RequeueDeadLetter},
+		{method: http.MethodGet, path: "/audit", name: "GetAuditLog", role: auth.RoleOperator, handler: c.GetAuditLog},
 	}
 
 	api := router.PathPrefix(apiV1Prefix).Subrouter()
@@ -37,7 +48,7 @@ func setRoutes(router *mux.Router, c Controller) {
 			Name(route.name).
 			Methods(route.method).
 			Path(route.path).
-			Handler(route.handler)
+			Handler(l.LimitUnauthorized(route.name, a.Require(route.role, l.Limit(route.name, route.handler))))
 	}
 }
 
@@ -51,4 +62,19 @@ func techRouter(router *mux.Router) {
 		Name("GetState").
 		Path("/tech/state").
 		HandlerFunc(tech.GetState)
+
+	router.Methods(http.MethodGet).
+		Name("GetLive").
+		Path("/tech/live").
+		HandlerFunc(tech.GetLive)
+
+	router.Methods(http.MethodGet).
+		Name("GetReady").
+		Path("/tech/ready").
+		HandlerFunc(tech.GetReady)
+
+	router.Methods(http.MethodGet).
+		Name("GetInfo").
+		Path("/tech/info").
+		HandlerFunc(tech.GetInfo)
 }
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/infrastructure/auth"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/requestid"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/tracing"
+	"github.com/Hashira21/currency-rate/internal/models"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// auditEntry заготавливает запись журнала: субъект и идентификатор запроса берутся из контекста,
+// старое и новое значения заполняет репозиторий в транзакции изменения
+func auditEntry(ctx context.Context, action, currency, base string) models.AuditEntry {
+	return models.AuditEntry{
+		Actor:     auth.FromContext(ctx).Subject,
+		Action:    action,
+		Currency:  currency,
+		Base:      base,
+		RequestId: requestid.FromContext(ctx),
+	}
+}
+
+// GetAuditLog возвращает журнал изменений, новые записи первыми
+func (svc *service) GetAuditLog(ctx context.Context, filter models.AuditFilter) (_ []models.AuditEntry, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetAuditLog")
+	defer func() { tracing.End(span, err) }()
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, fmt.Errorf("%w: start %s is after end %s",
+			ErrInvalidPeriod, filter.From.Format(time.RFC3339), filter.To.Format(time.RFC3339))
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	filter.Limit = min(filter.Limit, maxAuditLimit)
+
+	return svc.db.GetAuditLog(ctx, filter)
+}
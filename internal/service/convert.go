@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/infrastructure/tracing"
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	RoundingHalfEven = "half-even"
+	RoundingHalfUp   = "half-up"
+	RoundingDown     = "down"
+)
+
+var ErrInvalidRounding = errors.New("invalid rounding mode")
+
+// Convert пересчитывает amount из валюты fromIso в toIso по последнему курсу пары toIso/fromIso.
+// Результат округляется до количества знаков после запятой целевой валюты по ISO 4217.
+// Пересчёт валюты в саму себя идёт по курсу 1 без обращения к БД.
+func (svc *service) Convert(ctx context.Context, fromIso, toIso string, amount decimal.Decimal, rounding string) (_ models.ConversionResponse, err error) {
+	ctx, span := tracing.Start(ctx, "service.Convert")
+	defer func() { tracing.End(span, err) }()
+
+	if rounding == "" {
+		rounding = RoundingHalfEven
+	}
+
+	round, err := roundingFunc(rounding)
+	if err != nil {
+		return models.ConversionResponse{}, err
+	}
+
+	rate := models.CurrencyRateLast{Currency: toIso, Base: fromIso, Rate: decimal.NewFromInt(1), UpdateDt: time.Now()}
+	if fromIso != toIso {
+		rate, err = svc.GetLastRate(ctx, toIso, fromIso, false)
+		if err != nil {
+			return models.ConversionResponse{}, err
+		}
+	}
+
+	precision := models.MinorUnits(toIso)
+
+	return models.ConversionResponse{
+		From:      fromIso,
+		To:        toIso,
+		Amount:    amount,
+		Result:    round(amount.Mul(rate.Rate), precision),
+		Rate:      rate.Rate,
+		RateDt:    rate.UpdateDt,
+		Rounding:  rounding,
+		Precision: precision,
+	}, nil
+}
+
+func roundingFunc(rounding string) (func(d decimal.Decimal, places int32) decimal.Decimal, error) {
+	switch rounding {
+	case RoundingHalfEven:
+		return decimal.Decimal.RoundBank, nil
+	case RoundingHalfUp:
+		return decimal.Decimal.Round, nil
+	case RoundingDown:
+		return decimal.Decimal.RoundDown, nil
+	default:
+		return nil, fmt.Errorf("%w: %s, expected one of %s, %s, %s",
+			ErrInvalidRounding, rounding, RoundingHalfEven, RoundingHalfUp, RoundingDown)
+	}
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// edge — переход в графе пар: 1 единица from стоит rate единиц to
+type edge struct {
+	to   string
+	rate decimal.Decimal
+	pair models.CurrencyRateLast
+}
+
+// deriveRate вычисляет курс toIso/fromIso по сохранённым парам: сначала как обратный курс,
+// затем через опорную валюту, и наконец по кратчайшему пути в графе пар.
+// Для первых двух способов читаются только пары между toIso, fromIso и опорной валютой,
+// весь граф загружается лишь для поиска длинного пути.
+func (svc *service) deriveRate(ctx context.Context, toIso, fromIso string, excludeManual bool) (models.CurrencyRateLast, error) {
+	legs, err := svc.db.GetLastRatesAmong(ctx, []string{toIso, fromIso, svc.pivot}, excludeManual)
+	if err != nil {
+		return models.CurrencyRateLast{}, err
+	}
+
+	path := findPivotPath(buildGraph(legs), fromIso, toIso, svc.pivot)
+	if path == nil {
+		rates, err := svc.db.GetAllLastRates(ctx, excludeManual)
+		if err != nil {
+			return models.CurrencyRateLast{}, err
+		}
+
+		path = findPath(buildGraph(rates), fromIso, toIso)
+	}
+
+	if path == nil {
+		return models.CurrencyRateLast{}, pgx.ErrNoRows
+	}
+
+	result := models.CurrencyRateLast{
+		Currency: toIso,
+		Base:     fromIso,
+		Rate:     decimal.NewFromInt(1),
+		Source:   models.RateSourceDerived,
+		Derived:  true,
+		Legs:     make([]string, 0, len(path)),
+	}
+
+	for i, e := range path {
+		result.Rate = result.Rate.Mul(e.rate)
+		result.Legs = append(result.Legs, fmt.Sprintf("%s/%s", e.pair.Currency, e.pair.Base))
+
+		// Производный курс не может быть свежее самой старой из составляющих
+		if i == 0 || e.pair.UpdateDt.Before(result.UpdateDt) {
+			result.UpdateDt = e.pair.UpdateDt
+		}
+	}
+
+	svc.logger.Debug().Ctx(ctx).Msg(fmt.Sprintf("rate %s/%s derived from %v", toIso, fromIso, result.Legs))
+
+	return result, nil
+}
+
+func buildGraph(rates []models.CurrencyRateLast) map[string][]edge {
+	graph := make(map[string][]edge, len(rates))
+
+	for _, rate := range rates {
+		if !rate.Rate.IsPositive() {
+			continue
+		}
+
+		graph[rate.Base] = append(graph[rate.Base], edge{to: rate.Currency, rate: rate.Rate, pair: rate})
+		graph[rate.Currency] = append(graph[rate.Currency], edge{to: rate.Base, rate: decimal.NewFromInt(1).Div(rate.Rate), pair: rate})
+	}
+
+	return graph
+}
+
+func findEdge(graph map[string][]edge, from, to string) (edge, bool) {
+	for _, e := range graph[from] {
+		if e.to == to {
+			return e, true
+		}
+	}
+
+	return edge{}, false
+}
+
+// findPivotPath ищет обратный курс или путь из двух шагов через опорную валюту
+func findPivotPath(graph map[string][]edge, from, to, pivot string) []edge {
+	if e, ok := findEdge(graph, from, to); ok {
+		return []edge{e}
+	}
+
+	if from == pivot || to == pivot {
+		return nil
+	}
+
+	first, ok := findEdge(graph, from, pivot)
+	if !ok {
+		return nil
+	}
+
+	second, ok := findEdge(graph, pivot, to)
+	if !ok {
+		return nil
+	}
+
+	return []edge{first, second}
+}
+
+// findPath ищет кратчайший по количеству шагов путь в графе пар обходом в ширину
+func findPath(graph map[string][]edge, from, to string) []edge {
+	prev := map[string]edge{from: {}}
+	queue := []string{from}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		if node == to {
+			break
+		}
+
+		for _, e := range graph[node] {
+			if _, seen := prev[e.to]; seen {
+				continue
+			}
+
+			prev[e.to] = edge{to: node, rate: e.rate, pair: e.pair}
+			queue = append(queue, e.to)
+		}
+	}
+
+	if _, ok := prev[to]; !ok || from == to {
+		return nil
+	}
+
+	// Восстанавливаем путь с конца: в prev хранится предыдущая вершина и шаг, которым в неё пришли
+	var path []edge
+	for node := to; node != from; {
+		step := prev[node]
+		path = append([]edge{{to: node, rate: step.rate, pair: step.pair}}, path...)
+		node = step.to
+	}
+
+	return path
+}
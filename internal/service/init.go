@@ -1,19 +1,82 @@
 package service
 
 import (
+	"sync/atomic"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models/config"
 	"github.com/rs/zerolog"
 )
 
+const (
+	defaultPivot = "EUR"
+
+	defaultUpdateInterval = 30 * time.Second
+	defaultPollInterval   = time.Second
+	defaultPairTimeout    = 10 * time.Second
+
+	defaultBatchSize       = 100
+	defaultMaxAttempts     = 5
+	defaultRetryBackoff    = 5 * time.Second
+	defaultMaxRetryBackoff = 5 * time.Minute
+)
+
 type service struct {
-	frankfurterPrv FrankfurterPrv
-	db             Postgres
-	logger         zerolog.Logger
+	provider RateProvider
+	db       Postgres
+	pivot    string
+	logger   zerolog.Logger
+
+	// Интервал по умолчанию можно менять на лету, он применяется к парам без собственного интервала
+	updateInterval atomic.Int64
+	pollInterval   time.Duration
+	pairTimeout    time.Duration
+	jitter         float64
+
+	queue config.SyncRates
 }
 
-func New(frankfurterPrv FrankfurterPrv, db Postgres, logger zerolog.Logger) *service {
-	return &service{
-		frankfurterPrv: frankfurterPrv,
-		db:             db,
-		logger:         logger,
+func New(provider RateProvider, db Postgres, crossCfg config.CrossRates, updateCfg config.AutoUpdate,
+	queueCfg config.SyncRates, logger zerolog.Logger) *service {
+	pivot := crossCfg.Pivot
+	if pivot == "" {
+		pivot = defaultPivot
 	}
+
+	svc := &service{
+		provider:     provider,
+		db:           db,
+		pivot:        pivot,
+		logger:       logger,
+		pollInterval: defaultPollInterval,
+		pairTimeout:  defaultPairTimeout,
+		jitter:       updateCfg.Jitter,
+		queue:        queueCfg,
+	}
+
+	if svc.queue.BatchSize <= 0 {
+		svc.queue.BatchSize = defaultBatchSize
+	}
+	if svc.queue.MaxAttempts <= 0 {
+		svc.queue.MaxAttempts = defaultMaxAttempts
+	}
+	if svc.queue.RetryBackoff <= 0 {
+		svc.queue.RetryBackoff = defaultRetryBackoff
+	}
+	if svc.queue.MaxRetryBackoff <= 0 {
+		svc.queue.MaxRetryBackoff = defaultMaxRetryBackoff
+	}
+
+	svc.updateInterval.Store(int64(defaultUpdateInterval))
+	if updateCfg.Interval > 0 {
+		svc.updateInterval.Store(int64(updateCfg.Interval))
+	}
+	if updateCfg.PollInterval > 0 {
+		svc.pollInterval = updateCfg.PollInterval
+	}
+	if updateCfg.PairTimeout > 0 {
+		svc.pairTimeout = updateCfg.PairTimeout
+	}
+
+	return svc
 }
@@ -5,20 +5,35 @@ import (
 	"time"
 
 	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/shopspring/decimal"
 )
 
-type FrankfurterPrv interface {
-	GetRate(ctx context.Context, toIso, fromIso string) ([]byte, error)
+type RateProvider interface {
+	GetRate(ctx context.Context, toIso, fromIso string) (models.ProviderRates, string, error)
+	GetRates(ctx context.Context, fromIso string, toIsos []string) (models.ProviderRates, string, error)
+	GetRatesAt(ctx context.Context, fromIso string, toIsos []string, date time.Time) (models.ProviderRates, string, error)
+	GetTimeSeries(ctx context.Context, fromIso string, toIsos []string, start, end time.Time) ([]models.ProviderRates, string, error)
 }
 
 type Postgres interface {
-	AddToQueue(ctx context.Context, rate models.CurrencyRate) error
-	ConfirmQueue(ctx context.Context) (models.CurrencyRateWithDt, error)
-	GetById(ctx context.Context, id string) (models.CurrencyRateWithDt, error)
-	GetLastRate(ctx context.Context, toIso, fromIso string) (models.CurrencyRateLast, error)
-	GetPreviousRate(ctx context.Context, currency, base string) (models.CurrencyRateLast, error)
-	GetAllLastRates(ctx context.Context) ([]models.CurrencyRateLast, error)
-	DeleteByPair(ctx context.Context, currency, base string) error
-	UpdateRate(ctx context.Context, currency, base string, rate float64) error
-	GetHistoryRates(ctx context.Context, currency, base string, duration time.Duration) ([]models.CurrencyRateWithDt, error)
+	AddToQueue(ctx context.Context, rate models.CurrencyRate, audit models.AuditEntry) error
+	ProcessQueue(ctx context.Context, batchSize, maxAttempts int, backoff func(attempts int32) time.Duration) (models.QueueBatch, error)
+	GetDeadLetters(ctx context.Context, limit int) ([]models.DeadLetter, error)
+	RequeueDeadLetter(ctx context.Context, id string, audit models.AuditEntry) error
+	GetRateStatus(ctx context.Context, id string) (models.RateStatus, error)
+	GetLastRate(ctx context.Context, toIso, fromIso string, excludeManual bool) (models.CurrencyRateLast, error)
+	GetRateAt(ctx context.Context, currency, base string, at time.Time) (models.CurrencyRateWithDt, error)
+	GetPreviousRate(ctx context.Context, currency, base string, excludeManual bool) (models.CurrencyRateLast, error)
+	GetAllLastRates(ctx context.Context, excludeManual bool) ([]models.CurrencyRateLast, error)
+	GetLastRatesAmong(ctx context.Context, isoCodes []string, excludeManual bool) ([]models.CurrencyRateLast, error)
+	DeleteByPair(ctx context.Context, currency, base string, audit models.AuditEntry) error
+	UpdateRate(ctx context.Context, currency, base string, rate decimal.Decimal, audit models.AuditEntry) error
+	UpdateRates(ctx context.Context, base string, rates map[string]decimal.Decimal, source string, publishedDt time.Time) (time.Time, error)
+	AddHistoricalRates(ctx context.Context, rates []models.CurrencyRateWithDt, audit models.AuditEntry) (int64, error)
+	GetHistoryRates(ctx context.Context, currency, base string, duration time.Duration, excludeManual bool) ([]models.CurrencyRateWithDt, error)
+	GetPairs(ctx context.Context, status string) ([]models.Pair, error)
+	SavePair(ctx context.Context, pair models.Pair, audit models.AuditEntry) (models.Pair, error)
+	ArchivePair(ctx context.Context, currency, base string, audit models.AuditEntry) (models.Pair, error)
+	TrackPair(ctx context.Context, currency, base string) error
+	GetAuditLog(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error)
 }
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/infrastructure/metrics"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/tracing"
+	"github.com/Hashira21/currency-rate/internal/models"
+)
+
+// Минимальный интервал обновления пары, чтобы не упираться в лимиты провайдера
+const minPairInterval = 5 * time.Second
+
+var ErrInvalidPair = errors.New("invalid pair")
+
+// GetPairs возвращает пары реестра с указанным статусом; пустой status — все пары
+func (svc *service) GetPairs(ctx context.Context, status string) ([]models.Pair, error) {
+	switch status {
+	case "", models.PairActive, models.PairPaused, models.PairArchived:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidPair, status)
+	}
+
+	return svc.db.GetPairs(ctx, status)
+}
+
+// SavePair подписывает пару на автообновление или приостанавливает её. Нулевой интервал означает интервал по умолчанию.
+func (svc *service) SavePair(ctx context.Context, pair models.Pair) (_ models.Pair, err error) {
+	ctx, span := tracing.Start(ctx, "service.SavePair")
+	defer func() { tracing.End(span, err) }()
+
+	if pair.Currency == pair.Base {
+		return models.Pair{}, fmt.Errorf("%w: currency and base must differ", ErrInvalidPair)
+	}
+
+	switch pair.Status {
+	case "":
+		pair.Status = models.PairActive
+	case models.PairActive, models.PairPaused:
+	default:
+		return models.Pair{}, fmt.Errorf("%w: status must be %s or %s, archive a pair with DELETE",
+			ErrInvalidPair, models.PairActive, models.PairPaused)
+	}
+
+	if pair.IntervalSec < 0 || (pair.IntervalSec > 0 && time.Duration(pair.IntervalSec)*time.Second < minPairInterval) {
+		return models.Pair{}, fmt.Errorf("%w: interval must be at least %s", ErrInvalidPair, minPairInterval)
+	}
+
+	return svc.db.SavePair(ctx, pair, auditEntry(ctx, models.AuditPairSave, pair.Currency, pair.Base))
+}
+
+// ArchivePair прекращает отслеживание пары, сохраняя историю курсов
+func (svc *service) ArchivePair(ctx context.Context, currency, base string) (_ models.Pair, err error) {
+	ctx, span := tracing.Start(ctx, "service.ArchivePair")
+	defer func() { tracing.End(span, err) }()
+
+	pair, err := svc.db.ArchivePair(ctx, currency, base, auditEntry(ctx, models.AuditPairArchive, currency, base))
+	if err != nil {
+		return models.Pair{}, err
+	}
+
+	metrics.ForgetRate(currency, base)
+	return pair, nil
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/infrastructure/auth"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/metrics"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/tracing"
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Ограничение на время одного прохода, чтобы длинная очередь не копила параллельные запуски по расписанию
+const syncRatesBudget = 30 * time.Second
+
+// SyncRates разбирает очередь курсов пачками, пока в ней есть готовые к обработке строки, или до отмены ctx
+func (svc *service) SyncRates(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(auth.WithPrincipal(ctx, auth.System), syncRatesBudget)
+	defer cancel()
+
+	ctx, span := tracing.Start(ctx, "service.SyncRates")
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	for ctx.Err() == nil {
+		var batch models.QueueBatch
+		batch, err = svc.db.ProcessQueue(ctx, svc.queue.BatchSize, svc.queue.MaxAttempts, svc.retryBackoff)
+		if err != nil {
+			svc.logger.Error().Ctx(ctx).Msg(err.Error())
+			return
+		}
+
+		for _, rate := range batch.Applied {
+			metrics.ObserveRate(rate.Currency, rate.Base, rate.Rate, rate.UpdateDt)
+		}
+		metrics.ObserveQueueBatch(len(batch.Applied), batch.Retried, batch.DeadLettered)
+
+		if batch.Size() > 0 {
+			svc.logger.Info().Ctx(ctx).Msg(fmt.Sprintf("queue batch processed: %d applied, %d retried, %d dead-lettered",
+				len(batch.Applied), batch.Retried, batch.DeadLettered))
+		}
+
+		// Неполная пачка — готовых строк больше нет
+		if batch.Size() < svc.queue.BatchSize {
+			return
+		}
+	}
+}
+
+// retryBackoff — экспоненциальная задержка перед повтором: RetryBackoff, 2×, 4×… но не больше MaxRetryBackoff
+func (svc *service) retryBackoff(attempts int32) time.Duration {
+	delay := svc.queue.RetryBackoff
+	for i := int32(1); i < attempts && delay < svc.queue.MaxRetryBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, svc.queue.MaxRetryBackoff)
+}
+
+// Опрос БД при ожидании применения курса: первый повтор через statusPollInterval, далее интервал удваивается
+// до maxStatusPollInterval, чтобы долгое ожидание не пересчитывало позицию в очереди несколько раз в секунду
+const (
+	statusPollInterval    = 250 * time.Millisecond
+	maxStatusPollInterval = 2 * time.Second
+)
+
+// GetRateStatus возвращает состояние запроса на обновление курса. При wait > 0 ждёт, пока курс из очереди
+// не будет применён или не попадёт в dead-letter, но не дольше wait.
+func (svc *service) GetRateStatus(ctx context.Context, id string, wait time.Duration) (_ models.RateStatus, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetRateStatus")
+	defer func() { tracing.End(span, err) }()
+
+	span.SetAttributes(attribute.String("rate.id", id))
+	deadline := time.Now().Add(wait)
+	interval := statusPollInterval
+
+	for polls := 1; ; polls++ {
+		status, err := svc.db.GetRateStatus(ctx, id)
+		span.SetAttributes(attribute.Int("rate.status_polls", polls), attribute.String("rate.status", status.Status))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.RateStatus{Id: id, Status: models.RateNotFound}, nil
+		}
+
+		remaining := time.Until(deadline)
+		if err != nil || status.Status != models.RateQueued || remaining < statusPollInterval {
+			return status, err
+		}
+
+		select {
+		case <-ctx.Done():
+			// Клиент перестал ждать — отдаём последнее известное состояние
+			return status, nil
+		case <-time.After(min(interval, remaining)):
+		}
+
+		interval = min(2*interval, maxStatusPollInterval)
+	}
+}
+
+const defaultDeadLetterLimit = 100
+
+func (svc *service) GetDeadLetters(ctx context.Context, limit int) ([]models.DeadLetter, error) {
+	if limit <= 0 {
+		limit = defaultDeadLetterLimit
+	}
+
+	return svc.db.GetDeadLetters(ctx, limit)
+}
+
+// RequeueDeadLetter возвращает строку из dead-letter в очередь, она будет обработана при следующем проходе
+func (svc *service) RequeueDeadLetter(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "service.RequeueDeadLetter")
+	defer func() { tracing.End(span, err) }()
+
+	if err := svc.db.RequeueDeadLetter(ctx, id, auditEntry(ctx, models.AuditDeadLetterRequeue, "", "")); err != nil {
+		return err
+	}
+
+	svc.logger.Info().Ctx(ctx).Msg(fmt.Sprintf("dead-letter %s requeued", id))
+	return nil
+}
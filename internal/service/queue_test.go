@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/Hashira21/currency-rate/internal/models/config"
+	"github.com/rs/zerolog"
+)
+
+type queueDB struct {
+	Postgres
+
+	batches []models.QueueBatch
+	calls   int
+	backoff func(attempts int32) time.Duration
+
+	statuses []string
+	polls    int
+}
+
+func (db *queueDB) GetRateStatus(ctx context.Context, id string) (models.RateStatus, error) {
+	db.polls++
+	return models.RateStatus{Id: id, Status: db.statuses[min(db.polls, len(db.statuses))-1]}, nil
+}
+
+func (db *queueDB) ProcessQueue(ctx context.Context, batchSize, maxAttempts int,
+	backoff func(attempts int32) time.Duration) (models.QueueBatch, error) {
+	db.backoff = backoff
+	if db.calls == len(db.batches) {
+		return models.QueueBatch{}, nil
+	}
+
+	db.calls++
+	return db.batches[db.calls-1], nil
+}
+
+func newQueueService(db Postgres, queueCfg config.SyncRates) *service {
+	return New(nil, db, config.CrossRates{}, config.AutoUpdate{}, queueCfg, zerolog.Nop())
+}
+
+func TestRetryBackoff(t *testing.T) {
+	svc := newQueueService(nil, config.SyncRates{RetryBackoff: 5 * time.Second, MaxRetryBackoff: time.Minute})
+
+	tests := []struct {
+		attempts int32
+		want     time.Duration
+	}{
+		{attempts: 1, want: 5 * time.Second},
+		{attempts: 2, want: 10 * time.Second},
+		{attempts: 3, want: 20 * time.Second},
+		{attempts: 4, want: 40 * time.Second},
+		{attempts: 5, want: time.Minute},
+		{attempts: 50, want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := svc.retryBackoff(tt.attempts); got != tt.want {
+			t.Errorf("retryBackoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestSyncRatesDrainsFullBatches(t *testing.T) {
+	applied := func(n int) []models.CurrencyRateWithDt {
+		return make([]models.CurrencyRateWithDt, n)
+	}
+
+	tests := []struct {
+		name      string
+		batches   []models.QueueBatch
+		wantCalls int
+	}{
+		{
+			name:      "empty queue",
+			wantCalls: 0,
+		},
+		{
+			name:      "partial batch stops the pass",
+			batches:   []models.QueueBatch{{Applied: applied(1), Retried: 1}, {Applied: applied(3)}},
+			wantCalls: 1,
+		},
+		{
+			// Повторы и dead-letter тоже занимают место в пачке, после полной пачки очередь разбирается дальше
+			name: "full batches with failures",
+			batches: []models.QueueBatch{
+				{Applied: applied(1), Retried: 1, DeadLettered: 1},
+				{DeadLettered: 3},
+				{Applied: applied(2)},
+			},
+			wantCalls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &queueDB{batches: tt.batches}
+			svc := newQueueService(db, config.SyncRates{
+				BatchSize: 3, MaxAttempts: 5, RetryBackoff: time.Second, MaxRetryBackoff: time.Minute,
+			})
+
+			svc.SyncRates(context.Background())
+
+			if db.calls != tt.wantCalls {
+				t.Errorf("ProcessQueue consumed %d batches, want %d", db.calls, tt.wantCalls)
+			}
+			if db.backoff == nil || db.backoff(2) != 2*time.Second {
+				t.Error("ProcessQueue did not receive the service retry backoff")
+			}
+		})
+	}
+}
+
+func TestGetRateStatusPolling(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []string
+		wait       time.Duration
+		wantStatus string
+		maxPolls   int
+	}{
+		{
+			name:       "without wait",
+			statuses:   []string{models.RateQueued},
+			wantStatus: models.RateQueued,
+			maxPolls:   1,
+		},
+		{
+			name:       "applied while waiting",
+			statuses:   []string{models.RateQueued, models.RateQueued, models.RateApplied},
+			wait:       5 * time.Second,
+			wantStatus: models.RateApplied,
+			maxPolls:   3,
+		},
+		{
+			// Интервал удваивается: опросы через 0, 0.25, 0.75, 1.75 и 2 секунды, а не восемь раз каждые 250 мс
+			name:       "wait expires",
+			statuses:   []string{models.RateQueued},
+			wait:       2 * time.Second,
+			wantStatus: models.RateQueued,
+			maxPolls:   5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &queueDB{statuses: tt.statuses}
+			svc := newQueueService(db, config.SyncRates{})
+
+			status, err := svc.GetRateStatus(context.Background(), "id", tt.wait)
+			if err != nil {
+				t.Fatalf("GetRateStatus() error = %v", err)
+			}
+			if status.Status != tt.wantStatus || db.polls > tt.maxPolls {
+				t.Errorf("GetRateStatus() = %s after %d polls, want %s after at most %d", status.Status, db.polls, tt.wantStatus, tt.maxPolls)
+			}
+		})
+	}
+}
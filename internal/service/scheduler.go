@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/infrastructure/auth"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/metrics"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/tracing"
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type pairKey struct {
+	currency string
+	base     string
+}
+
+// pairState — состояние пары в планировщике. running выставляется горутиной обновления,
+// остальные поля меняются только в цикле AutoUpdateRates.
+type pairState struct {
+	interval time.Duration
+	lastRun  time.Time
+	nextRun  time.Time
+	running  atomic.Bool
+}
+
+// AutoUpdateRates обновляет курсы пар по их собственному расписанию до отмены ctx.
+// Пары, которым пора обновляться, группируются по базовой валюте, и каждая группа обновляется в своей горутине
+// одним запросом к провайдеру; если предыдущее обновление пары ещё идёт, её запуск пропускается.
+// При остановке начатые обновления прерываются, и AutoUpdateRates ждёт их завершения.
+func (svc *service) AutoUpdateRates(ctx context.Context) error {
+	ctx = auth.WithPrincipal(ctx, auth.System)
+
+	ticker := time.NewTicker(svc.pollInterval)
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	states := make(map[pairKey]*pairState)
+
+	for {
+		select {
+		case <-ctx.Done():
+			svc.logger.Info().Ctx(ctx).Msg("Автообновление курсов остановлено")
+			return nil
+		case <-ticker.C:
+			if err := svc.runDuePairs(ctx, states, &wg); err != nil {
+				svc.logger.Error().Ctx(ctx).Msg(fmt.Sprintf("Ошибка автообновления курсов: %v", err))
+			}
+		}
+	}
+}
+
+// SetAutoUpdateInterval меняет интервал по умолчанию без перезапуска
+func (svc *service) SetAutoUpdateInterval(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	svc.updateInterval.Store(int64(interval))
+	svc.logger.Info().Msg(fmt.Sprintf("Интервал автообновления курсов по умолчанию изменён на %s", interval))
+}
+
+func (svc *service) runDuePairs(ctx context.Context, states map[pairKey]*pairState, wg *sync.WaitGroup) error {
+	pollCtx, cancel := context.WithTimeout(ctx, svc.pollInterval)
+	defer cancel()
+
+	pairs, err := svc.db.GetPairs(pollCtx, models.PairActive)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	seen := make(map[pairKey]bool, len(pairs))
+	// Пары, которым пора обновляться, по базовой валюте: котировки к одной базе запрашиваются одним вызовом
+	due := make(map[string][]pairKey)
+
+	for _, pair := range pairs {
+		key := pairKey{currency: pair.Currency, base: pair.Base}
+		seen[key] = true
+
+		interval := svc.pairInterval(pair)
+		state, ok := states[key]
+		if !ok {
+			// Новые пары стартуют в случайный момент первого интервала, чтобы не обновлять всё разом
+			state = &pairState{interval: interval, nextRun: now.Add(firstRunOffset(interval))}
+			states[key] = state
+		}
+		if state.interval != interval {
+			// До первого запуска отсчитываем от текущего момента, иначе пара обновится сразу же
+			from := state.lastRun
+			if from.IsZero() {
+				from = now
+			}
+
+			state.interval = interval
+			state.nextRun = from.Add(svc.withJitter(interval))
+		}
+
+		if now.Before(state.nextRun) {
+			continue
+		}
+
+		if !state.running.CompareAndSwap(false, true) {
+			// Пропущенный запуск не повторяем на каждом тике, ждём следующего по расписанию
+			state.nextRun = now.Add(svc.withJitter(interval))
+			svc.logger.Debug().Ctx(ctx).Msg(fmt.Sprintf("Обновление %s/%s ещё выполняется, запуск пропущен", key.currency, key.base))
+			metrics.ObservePairRefresh(metrics.RefreshSkipped)
+			continue
+		}
+
+		state.lastRun = now
+		state.nextRun = now.Add(svc.withJitter(interval))
+		due[key.base] = append(due[key.base], key)
+	}
+
+	for base, keys := range due {
+		currencies := make([]string, 0, len(keys))
+		running := make([]*pairState, 0, len(keys))
+		for _, key := range keys {
+			currencies = append(currencies, key.currency)
+			running = append(running, states[key])
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				for _, state := range running {
+					state.running.Store(false)
+				}
+			}()
+
+			svc.refreshBase(ctx, base, currencies)
+		}()
+	}
+
+	// Пары, приостановленные, архивированные или удалённые из реестра, забываем. Пока обновление пары ещё идёт,
+	// состояние с флагом running сохраняем: иначе возобновлённая пара обновлялась бы параллельно с ним.
+	for key, state := range states {
+		if !seen[key] && !state.running.Load() {
+			delete(states, key)
+		}
+	}
+
+	return nil
+}
+
+// refreshBase одним запросом к провайдеру получает курсы валют currencies к base и сохраняет их.
+// Обновление ограничено таймаутом пары и прерывается остановкой сервиса.
+func (svc *service) refreshBase(ctx context.Context, base string, currencies []string) {
+	ctx, cancel := context.WithTimeout(ctx, svc.pairTimeout)
+	defer cancel()
+
+	ctx, span := tracing.Start(ctx, "service.refreshBase")
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	span.SetAttributes(attribute.String("rate.base", base), attribute.Int("rate.currencies", len(currencies)))
+
+	rateData, providerName, err := svc.provider.GetRates(ctx, base, currencies)
+	if err != nil {
+		svc.logger.Warn().Ctx(ctx).Msg(fmt.Sprintf("Не удалось обновить курсы %v к %s: %v", currencies, base, err))
+		for range currencies {
+			metrics.ObservePairRefresh(metrics.RefreshFailed)
+		}
+		return
+	}
+
+	rates := make(map[string]decimal.Decimal, len(currencies))
+	for _, currency := range currencies {
+		quote, ok := rateData.Rates[currency]
+		if !ok {
+			svc.logger.Warn().Ctx(ctx).Msg(fmt.Sprintf("provider %s returned no rate for %s/%s", providerName, currency, base))
+			metrics.ObservePairRefresh(metrics.RefreshFailed)
+			continue
+		}
+
+		rates[currency] = quote.Div(rateData.Amount)
+	}
+
+	if len(rates) == 0 {
+		err = fmt.Errorf("provider %s returned no rates for %v to %s", providerName, currencies, base)
+		return
+	}
+
+	_, err = svc.db.UpdateRates(ctx, base, rates, providerName, rateData.Date)
+	if err != nil {
+		svc.logger.Warn().Ctx(ctx).Msg(fmt.Sprintf("Ошибка сохранения курсов к %s: %v", base, err))
+		for range rates {
+			metrics.ObservePairRefresh(metrics.RefreshFailed)
+		}
+		return
+	}
+
+	now := time.Now()
+	for currency, rate := range rates {
+		metrics.ObserveRate(currency, base, rate, now)
+		metrics.ObservePairRefresh(metrics.RefreshSucceeded)
+	}
+
+	svc.logger.Debug().Ctx(ctx).Msg(fmt.Sprintf("Курсы %d валют к %s получены от провайдера %s", len(rates), base, providerName))
+}
+
+func (svc *service) pairInterval(pair models.Pair) time.Duration {
+	if pair.IntervalSec > 0 {
+		return time.Duration(pair.IntervalSec) * time.Second
+	}
+
+	return time.Duration(svc.updateInterval.Load())
+}
+
+// firstRunOffset — равномерно случайная задержка первого обновления пары в пределах [0, interval)
+func firstRunOffset(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+
+	return rand.N(interval)
+}
+
+// withJitter случайно сдвигает интервал в пределах ±jitter от его длины
+func (svc *service) withJitter(interval time.Duration) time.Duration {
+	if svc.jitter <= 0 {
+		return interval
+	}
+
+	shift := (rand.Float64()*2 - 1) * svc.jitter
+
+	return interval + time.Duration(float64(interval)*shift)
+}
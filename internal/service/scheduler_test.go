@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/Hashira21/currency-rate/internal/models/config"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+)
+
+type schedulerDB struct {
+	Postgres
+
+	pairs []models.Pair
+
+	mu      sync.Mutex
+	updated map[string]map[string]decimal.Decimal
+}
+
+func (db *schedulerDB) GetPairs(ctx context.Context, status string) ([]models.Pair, error) {
+	return db.pairs, nil
+}
+
+func (db *schedulerDB) UpdateRates(ctx context.Context, base string, rates map[string]decimal.Decimal, source string, publishedDt time.Time) (time.Time, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.updated[base] = rates
+	return time.Now(), nil
+}
+
+type schedulerProvider struct {
+	RateProvider
+
+	mu    sync.Mutex
+	calls map[string][]string
+}
+
+func (prv *schedulerProvider) GetRates(ctx context.Context, fromIso string, toIsos []string) (models.ProviderRates, string, error) {
+	prv.mu.Lock()
+	defer prv.mu.Unlock()
+
+	prv.calls[fromIso] = append(prv.calls[fromIso], toIsos...)
+
+	rates := make(map[string]decimal.Decimal, len(toIsos))
+	for _, currency := range toIsos {
+		rates[currency] = decimal.NewFromInt(2)
+	}
+
+	return models.ProviderRates{Amount: decimal.NewFromInt(1), Base: fromIso, Date: time.Now(), Rates: rates}, "fake", nil
+}
+
+func newSchedulerService(pairs ...models.Pair) (*service, *schedulerDB, *schedulerProvider) {
+	db := &schedulerDB{pairs: pairs, updated: make(map[string]map[string]decimal.Decimal)}
+	prv := &schedulerProvider{calls: make(map[string][]string)}
+
+	svc := New(prv, db, config.CrossRates{}, config.AutoUpdate{Interval: time.Minute, Jitter: 0.1},
+		config.SyncRates{}, zerolog.Nop())
+
+	return svc, db, prv
+}
+
+func activePair(currency, base string) models.Pair {
+	return models.Pair{Currency: currency, Base: base, Status: models.PairActive}
+}
+
+// dueStates возвращает состояния, по которым все пары должны обновиться на ближайшем проходе
+func dueStates(svc *service, pairs ...models.Pair) map[pairKey]*pairState {
+	states := make(map[pairKey]*pairState, len(pairs))
+	for _, pair := range pairs {
+		states[pairKey{currency: pair.Currency, base: pair.Base}] = &pairState{
+			interval: svc.pairInterval(pair),
+			lastRun:  time.Now().Add(-time.Hour),
+			nextRun:  time.Now().Add(-time.Second),
+		}
+	}
+
+	return states
+}
+
+func TestRunDuePairsBatchesByBase(t *testing.T) {
+	pairs := []models.Pair{activePair("USD", "EUR"), activePair("GBP", "EUR"), activePair("EUR", "USD")}
+	svc, db, prv := newSchedulerService(pairs...)
+	states := dueStates(svc, pairs...)
+
+	var wg sync.WaitGroup
+	if err := svc.runDuePairs(context.Background(), states, &wg); err != nil {
+		t.Fatalf("runDuePairs() error = %v", err)
+	}
+	wg.Wait()
+
+	if len(prv.calls) != 2 {
+		t.Fatalf("provider called for bases %v, want one call per base", prv.calls)
+	}
+	eur := slices.Sorted(slices.Values(prv.calls["EUR"]))
+	if !slices.Equal(eur, []string{"GBP", "USD"}) {
+		t.Errorf("EUR batch = %v, want [GBP USD]", eur)
+	}
+	if len(db.updated["EUR"]) != 2 || len(db.updated["USD"]) != 1 {
+		t.Errorf("saved rates = %v", db.updated)
+	}
+
+	for key, state := range states {
+		if state.running.Load() {
+			t.Errorf("%v still marked running", key)
+		}
+		if !state.nextRun.After(time.Now()) {
+			t.Errorf("%v nextRun = %s, want in the future", key, state.nextRun)
+		}
+	}
+}
+
+func TestRunDuePairsSkipsRunning(t *testing.T) {
+	pair := activePair("USD", "EUR")
+	svc, _, prv := newSchedulerService(pair)
+	states := dueStates(svc, pair)
+
+	state := states[pairKey{currency: "USD", base: "EUR"}]
+	state.running.Store(true)
+
+	var wg sync.WaitGroup
+	if err := svc.runDuePairs(context.Background(), states, &wg); err != nil {
+		t.Fatalf("runDuePairs() error = %v", err)
+	}
+	wg.Wait()
+
+	if len(prv.calls) != 0 {
+		t.Errorf("provider called %v while the previous refresh is running", prv.calls)
+	}
+	if !state.nextRun.After(time.Now()) {
+		t.Errorf("skipped run is not postponed: nextRun = %s", state.nextRun)
+	}
+}
+
+func TestRunDuePairsIntervalChangeBeforeFirstRun(t *testing.T) {
+	pair := activePair("USD", "EUR")
+	pair.IntervalSec = 600
+	svc, _, prv := newSchedulerService(pair)
+
+	// Пара ещё ни разу не обновлялась, а интервал успел смениться
+	states := map[pairKey]*pairState{
+		{currency: "USD", base: "EUR"}: {interval: time.Minute, nextRun: time.Now().Add(30 * time.Second)},
+	}
+
+	var wg sync.WaitGroup
+	if err := svc.runDuePairs(context.Background(), states, &wg); err != nil {
+		t.Fatalf("runDuePairs() error = %v", err)
+	}
+	wg.Wait()
+
+	if len(prv.calls) != 0 {
+		t.Errorf("provider called %v right after interval change", prv.calls)
+	}
+
+	state := states[pairKey{currency: "USD", base: "EUR"}]
+	if until := time.Until(state.nextRun); until < 9*time.Minute {
+		t.Errorf("nextRun in %s, want about 10m from now", until)
+	}
+}
+
+func TestRunDuePairsForgetsInactive(t *testing.T) {
+	svc, _, _ := newSchedulerService()
+	states := dueStates(svc, activePair("USD", "EUR"))
+
+	var wg sync.WaitGroup
+	if err := svc.runDuePairs(context.Background(), states, &wg); err != nil {
+		t.Fatalf("runDuePairs() error = %v", err)
+	}
+	wg.Wait()
+
+	if len(states) != 0 {
+		t.Errorf("states = %v, want paused and archived pairs forgotten", states)
+	}
+}
+
+func TestRunDuePairsKeepsRunningInactive(t *testing.T) {
+	svc, _, _ := newSchedulerService()
+	states := dueStates(svc, activePair("USD", "EUR"))
+
+	// Пару приостановили, пока её обновление ещё выполняется
+	key := pairKey{currency: "USD", base: "EUR"}
+	states[key].running.Store(true)
+
+	var wg sync.WaitGroup
+	if err := svc.runDuePairs(context.Background(), states, &wg); err != nil {
+		t.Fatalf("runDuePairs() error = %v", err)
+	}
+	wg.Wait()
+
+	if _, ok := states[key]; !ok {
+		t.Fatal("state of a running pair forgotten, a resumed pair would refresh concurrently")
+	}
+
+	states[key].running.Store(false)
+	if err := svc.runDuePairs(context.Background(), states, &wg); err != nil {
+		t.Fatalf("runDuePairs() error = %v", err)
+	}
+	if len(states) != 0 {
+		t.Errorf("states = %v, want the pair forgotten once its refresh finished", states)
+	}
+}
+
+func TestRunDuePairsSpreadsFirstRuns(t *testing.T) {
+	pairs := make([]models.Pair, 0, 20)
+	for _, currency := range []string{"AUD", "CAD", "CHF", "CNY", "CZK", "DKK", "GBP", "HKD", "HUF", "ILS",
+		"INR", "JPY", "KRW", "MXN", "NOK", "NZD", "PLN", "SEK", "SGD", "USD"} {
+		pairs = append(pairs, activePair(currency, "EUR"))
+	}
+	svc, _, _ := newSchedulerService(pairs...)
+	svc.jitter = 0
+
+	states := make(map[pairKey]*pairState)
+	var wg sync.WaitGroup
+	start := time.Now()
+	if err := svc.runDuePairs(context.Background(), states, &wg); err != nil {
+		t.Fatalf("runDuePairs() error = %v", err)
+	}
+	wg.Wait()
+
+	// Без джиттера первые запуски всё равно должны быть разнесены по первому интервалу
+	distinct := make(map[time.Time]bool, len(states))
+	for key, state := range states {
+		if offset := state.nextRun.Sub(start); offset < 0 || offset > time.Minute {
+			t.Errorf("%v first run in %s, want within [0, 1m)", key, offset)
+		}
+		distinct[state.nextRun] = true
+	}
+	if len(distinct) < len(states)/2 {
+		t.Errorf("%d distinct first runs for %d pairs, want them spread", len(distinct), len(states))
+	}
+}
+
+func TestWithJitter(t *testing.T) {
+	svc, _, _ := newSchedulerService()
+	interval := 10 * time.Second
+
+	for range 1000 {
+		if got := svc.withJitter(interval); got < 9*time.Second || got > 11*time.Second {
+			t.Fatalf("withJitter(%s) = %s, want within ±10%%", interval, got)
+		}
+	}
+
+	svc.jitter = 0
+	if got := svc.withJitter(interval); got != interval {
+		t.Errorf("withJitter() without jitter = %s, want %s", got, interval)
+	}
+}
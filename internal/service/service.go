@@ -7,171 +7,264 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Hashira21/currency-rate/internal/infrastructure/auth"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/metrics"
+	"github.com/Hashira21/currency-rate/internal/infrastructure/tracing"
 	"github.com/Hashira21/currency-rate/internal/models"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
 )
 
-func (svc *service) GetRateFromProvider(ctx context.Context, toIso, fromIso string) (models.UpdateResponse, error) {
-	respBody, err := svc.frankfurterPrv.GetRate(ctx, toIso, fromIso)
-	if err != nil {
-		return models.UpdateResponse{}, err
-	}
+var ErrInvalidPeriod = errors.New("invalid period")
 
-	var rate map[string]interface{}
-	if err_ := json.Unmarshal(respBody, &rate); err_ != nil {
-		svc.logger.Error().Msg(err_.Error())
-		return models.UpdateResponse{}, err_
-	}
+func (svc *service) GetRateFromProvider(ctx context.Context, toIso, fromIso string) (_ models.UpdateResponse, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetRateFromProvider")
+	defer func() { tracing.End(span, err) }()
 
-	if rate["rates"].(map[string]interface{})[toIso] == nil || rate["base"] != fromIso {
-		err_ := errors.New("get incorrect value from frankfurter")
-		svc.logger.Error().Msg(err_.Error())
-		return models.UpdateResponse{}, err_
+	rate, providerName, err := svc.provider.GetRate(ctx, toIso, fromIso)
+	if err != nil {
+		return models.UpdateResponse{}, err
 	}
 
 	currRate := models.CurrencyRate{
-		Id:       uuid.New().String(),
-		Currency: toIso,
-		Base:     fromIso,
-		Rate:     float32(rate["rates"].(map[string]interface{})[toIso].(float64)),
+		Id:          uuid.New().String(),
+		Currency:    toIso,
+		Base:        fromIso,
+		Rate:        rate.Rates[toIso].Div(rate.Amount),
+		Provider:    providerName,
+		PublishedDt: rate.Date,
 	}
 
-	if err_ := svc.db.AddToQueue(ctx, currRate); err_ != nil {
+	if err_ := svc.db.AddToQueue(ctx, currRate, auditEntry(ctx, models.AuditRateRefresh, toIso, fromIso)); err_ != nil {
 		return models.UpdateResponse{}, err_
 	}
 
-	svc.logger.Info().Msg(fmt.Sprintf("succesfully added to queue: %+v", currRate))
+	svc.logger.Info().Ctx(ctx).Msg(fmt.Sprintf("succesfully added to queue: %+v", currRate))
 
-	rateResp := models.UpdateResponse{RateId: currRate.Id}
+	rateResp := models.UpdateResponse{RateId: currRate.Id, Provider: providerName}
 
 	return rateResp, err
 }
 
-func (svc *service) GetById(ctx context.Context, id string) (models.CurrencyRateWithDt, error) {
-	return svc.db.GetById(ctx, id)
-}
+// GetLastRate возвращает последний курс пары или производный курс, если пары нет в БД.
+// При excludeManual ручные установки курса не учитываются.
+func (svc *service) GetLastRate(ctx context.Context, toIso, fromIso string, excludeManual bool) (_ models.CurrencyRateLast, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetLastRate")
+	defer func() { tracing.End(span, err) }()
+
+	rate, err := svc.db.GetLastRate(ctx, toIso, fromIso, excludeManual)
+	if err == nil || !errors.Is(err, pgx.ErrNoRows) {
+		return rate, err
+	}
 
-func (svc *service) GetLastRate(ctx context.Context, toIso, fromIso string) (models.CurrencyRateLast, error) {
-	return svc.db.GetLastRate(ctx, toIso, fromIso)
+	// Пары нет в БД — пробуем вычислить кросс-курс через сохранённые пары
+	return svc.deriveRate(ctx, toIso, fromIso, excludeManual)
 }
 
-func (svc *service) SyncRates() {
-	ctx := context.Background()
+const (
+	RateSourceLocal    = "local"
+	RateSourceProvider = "provider"
+)
+
+// GetRateAt возвращает курс, действовавший в момент at. Если локальные данные не покрывают день at,
+// запрашивает курс у провайдера на дату и сохраняет его на момент at с датой публикации провайдера,
+// чтобы следующие запросы на этот день обслуживались из БД.
+func (svc *service) GetRateAt(ctx context.Context, toIso, fromIso string, at time.Time) (_ models.CurrencyRateAt, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetRateAt")
+	defer func() { tracing.End(span, err) }()
 
-	rate, err := svc.db.ConfirmQueue(ctx)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return
+	if at.After(time.Now()) {
+		return models.CurrencyRateAt{}, fmt.Errorf("%w: date %s is in the future", ErrInvalidPeriod, at.Format(time.RFC3339))
+	}
+
+	rate, err := svc.db.GetRateAt(ctx, toIso, fromIso, at)
+	if err == nil {
+		updateDt := rate.UpdateDt
+		if rate.PublishedDt != nil {
+			updateDt = *rate.PublishedDt
 		}
 
-		svc.logger.Error().Msg(err.Error())
-		return
+		return models.CurrencyRateAt{
+			Currency:    rate.Currency,
+			Base:        rate.Base,
+			Rate:        rate.Rate,
+			UpdateDt:    updateDt,
+			RequestedDt: at,
+			Source:      RateSourceLocal,
+		}, nil
 	}
 
-	svc.logger.Info().Msg(fmt.Sprintf("rate updated successfully: %+v", rate))
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return models.CurrencyRateAt{}, err
+	}
+
+	rateData, providerName, err := svc.provider.GetRatesAt(ctx, fromIso, []string{toIso}, at)
+	if err != nil {
+		return models.CurrencyRateAt{}, err
+	}
+
+	quote, ok := rateData.Rates[toIso]
+	if !ok {
+		return models.CurrencyRateAt{}, pgx.ErrNoRows
+	}
+
+	result := models.CurrencyRateAt{
+		Currency:    toIso,
+		Base:        fromIso,
+		Rate:        quote.Div(rateData.Amount),
+		UpdateDt:    rateData.Date,
+		RequestedDt: at,
+		Source:      RateSourceProvider,
+	}
+
+	// Сохранение ответа провайдера — кэширование чтения, а не изменение данных пользователем
+	entry := auditEntry(auth.WithPrincipal(ctx, auth.System), models.AuditRatesBackfill, toIso, fromIso)
+	entry.NewValue, _ = json.Marshal(map[string]any{
+		"from":     result.UpdateDt.Format(time.DateOnly),
+		"to":       result.UpdateDt.Format(time.DateOnly),
+		"provider": providerName,
+	})
+
+	_, err = svc.db.AddHistoricalRates(ctx, []models.CurrencyRateWithDt{{
+		Currency:    result.Currency,
+		Base:        result.Base,
+		Rate:        result.Rate,
+		UpdateDt:    at,
+		Source:      providerName,
+		PublishedDt: &rateData.Date,
+	}}, entry)
+	if err != nil {
+		svc.logger.Warn().Ctx(ctx).Msg(fmt.Sprintf("failed to cache rate %s/%s on %s from %s: %v",
+			toIso, fromIso, result.UpdateDt.Format(time.DateOnly), providerName, err))
+	}
+
+	return result, nil
 }
 
-func (svc *service) GetAllLastRates(ctx context.Context) ([]models.CurrencyRateLast, error) {
-	latestRates, err := svc.db.GetAllLastRates(ctx)
+// GetAllLastRates возвращает последние курсы всех пар с изменением к предыдущему курсу.
+// При excludeManual ручные установки курса не учитываются ни в последнем, ни в предыдущем курсе.
+func (svc *service) GetAllLastRates(ctx context.Context, excludeManual bool) (_ []models.CurrencyRateLast, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetAllLastRates")
+	defer func() { tracing.End(span, err) }()
+
+	latestRates, err := svc.db.GetAllLastRates(ctx, excludeManual)
 	if err != nil {
 		return nil, err
 	}
 
 	for i := range latestRates {
-		prevRate, err := svc.db.GetPreviousRate(ctx, latestRates[i].Currency, latestRates[i].Base)
+		prevRate, err := svc.db.GetPreviousRate(ctx, latestRates[i].Currency, latestRates[i].Base, excludeManual)
 		if err != nil {
-			svc.logger.Warn().Msg(fmt.Sprintf("Не удалось получить предыдущий курс для %s/%s: %v", latestRates[i].Currency, latestRates[i].Base, err))
+			svc.logger.Warn().Ctx(ctx).Msg(fmt.Sprintf("Не удалось получить предыдущий курс для %s/%s: %v", latestRates[i].Currency, latestRates[i].Base, err))
 			continue
 		}
 
-		if prevRate.Rate > 0 {
-			latestRates[i].ChangePct = ((latestRates[i].Rate - prevRate.Rate) / prevRate.Rate) * 100
-		} else {
-			latestRates[i].ChangePct = 0
-		}
+		latestRates[i].ChangePct = models.ChangePct(latestRates[i].Rate, prevRate.Rate)
 	}
 
 	return latestRates, nil
 }
 
-func (svc *service) DeleteByPair(ctx context.Context, currency, base string) error {
-	return svc.db.DeleteByPair(ctx, currency, base)
-}
+func (svc *service) DeleteByPair(ctx context.Context, currency, base string) (err error) {
+	ctx, span := tracing.Start(ctx, "service.DeleteByPair")
+	defer func() { tracing.End(span, err) }()
 
-func (svc *service) UpdateRate(ctx context.Context, currency, base string, rate float64) error {
-	return svc.db.UpdateRate(ctx, currency, base, rate)
+	if err := svc.db.DeleteByPair(ctx, currency, base, auditEntry(ctx, models.AuditPairDelete, currency, base)); err != nil {
+		return err
+	}
+
+	metrics.ForgetRate(currency, base)
+	return nil
 }
 
-// Метод для автоматического обновления курсов
-func (svc *service) AutoUpdateRates() {
-	ticker := time.NewTicker(30 * time.Second) // Обновление раз минуту
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			svc.logger.Info().Msg("Запуск автоматического обновления курсов...")
-			err := svc.updateAllRates()
-			if err != nil {
-				svc.logger.Error().Msg(fmt.Sprintf("Ошибка автообновления курсов: %v", err))
-			}
-		}
+func (svc *service) UpdateRate(ctx context.Context, currency, base string, rate decimal.Decimal) (err error) {
+	ctx, span := tracing.Start(ctx, "service.UpdateRate")
+	defer func() { tracing.End(span, err) }()
+
+	if err := svc.db.UpdateRate(ctx, currency, base, rate, auditEntry(ctx, models.AuditRateUpdate, currency, base)); err != nil {
+		return err
 	}
+
+	metrics.ObserveRate(currency, base, rate, time.Now())
+
+	if err := svc.db.TrackPair(ctx, currency, base); err != nil {
+		svc.logger.Warn().Ctx(ctx).Msg(fmt.Sprintf("failed to register pair %s/%s: %v", currency, base, err))
+	}
+
+	return nil
 }
 
-// Вспомогательный метод обновления всех курсов
-func (svc *service) updateAllRates() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// Backfill загружает дневные курсы пары за период [from, to] и сохраняет их с историческими датами
+func (svc *service) Backfill(ctx context.Context, currency, base string, from, to time.Time) (_ models.BackfillResponse, err error) {
+	ctx, span := tracing.Start(ctx, "service.Backfill")
+	defer func() { tracing.End(span, err) }()
 
-	// Получаем все доступные валютные пары
-	rates, err := svc.db.GetAllLastRates(ctx)
-	if err != nil {
-		return err
+	if to.Before(from) {
+		return models.BackfillResponse{}, fmt.Errorf("%w: start date %s is after end date %s",
+			ErrInvalidPeriod, from.Format(time.DateOnly), to.Format(time.DateOnly))
 	}
 
-	for _, rate := range rates {
-		// Запрашиваем новый курс у API
-		respBody, err := svc.frankfurterPrv.GetRate(ctx, rate.Currency, rate.Base)
-		if err != nil {
-			svc.logger.Warn().Msg(fmt.Sprintf("Не удалось обновить курс для %s/%s: %v", rate.Currency, rate.Base, err))
-			continue
-		}
+	if to.After(time.Now()) {
+		return models.BackfillResponse{}, fmt.Errorf("%w: end date %s is in the future", ErrInvalidPeriod, to.Format(time.DateOnly))
+	}
 
-		// Разбираем JSON-ответ
-		var rateData map[string]interface{}
-		if err := json.Unmarshal(respBody, &rateData); err != nil {
-			svc.logger.Warn().Msg(fmt.Sprintf("Ошибка парсинга JSON для %s/%s: %v", rate.Currency, rate.Base, err))
-			continue
-		}
+	series, providerName, err := svc.provider.GetTimeSeries(ctx, base, []string{currency}, from, to)
+	if err != nil {
+		return models.BackfillResponse{}, err
+	}
 
-		// Проверяем, есть ли нужные данные в JSON
-		if rateData["rates"] == nil || rateData["rates"].(map[string]interface{})[rate.Currency] == nil {
-			svc.logger.Warn().Msg(fmt.Sprintf("Некорректный JSON-ответ для %s/%s: %v", rate.Currency, rate.Base, rateData))
+	rates := make([]models.CurrencyRateWithDt, 0, len(series))
+	for _, day := range series {
+		quote, ok := day.Rates[currency]
+		if !ok {
 			continue
 		}
 
-		// Получаем курс
-		newRate := rateData["rates"].(map[string]interface{})[rate.Currency].(float64)
+		rates = append(rates, models.CurrencyRateWithDt{
+			Currency:    currency,
+			Base:        base,
+			Rate:        quote.Div(day.Amount),
+			UpdateDt:    day.Date,
+			Source:      models.RateSourceBackfill,
+			PublishedDt: &day.Date,
+		})
+	}
 
-		// Сохраняем обновлённый курс в БД
-		err = svc.db.UpdateRate(ctx, rate.Currency, rate.Base, newRate)
-		if err != nil {
-			svc.logger.Warn().Msg(fmt.Sprintf("Ошибка сохранения нового курса %s/%s: %v", rate.Currency, rate.Base, err))
-		}
+	// Загрузка истории только добавляет недостающие дни, поэтому в журнал пишется итог, а не старые значения;
+	// количество полученных и добавленных курсов дописывает репозиторий
+	entry := auditEntry(ctx, models.AuditRatesBackfill, currency, base)
+	entry.NewValue, _ = json.Marshal(map[string]any{
+		"from":     from.Format(time.DateOnly),
+		"to":       to.Format(time.DateOnly),
+		"provider": providerName,
+	})
+
+	inserted, err := svc.db.AddHistoricalRates(ctx, rates, entry)
+	if err != nil {
+		return models.BackfillResponse{}, err
 	}
 
-	return nil
+	svc.logger.Info().Ctx(ctx).Msg(fmt.Sprintf("backfill %s/%s from %s: received %d rates, inserted %d",
+		currency, base, providerName, len(rates), inserted))
+
+	return models.BackfillResponse{
+		Received: len(rates),
+		Inserted: inserted,
+		Provider: providerName,
+	}, nil
 }
 
-func (svc *service) GetHistory(ctx context.Context, currency, base, period string) ([]models.CurrencyRateWithDt, error) {
+func (svc *service) GetHistory(ctx context.Context, currency, base, period string, excludeManual bool) (_ []models.CurrencyRateWithDt, err error) {
+	ctx, span := tracing.Start(ctx, "service.GetHistory")
+	defer func() { tracing.End(span, err) }()
+
 	duration, err := parseDuration(period)
 	if err != nil {
 		return nil, err
 	}
 
-	return svc.db.GetHistoryRates(ctx, currency, base, duration)
+	return svc.db.GetHistoryRates(ctx, currency, base, duration, excludeManual)
 }
 
 // Вспомогательная функция для конвертации периода
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Hashira21/currency-rate/internal/infrastructure/auth"
+	"github.com/Hashira21/currency-rate/internal/models"
+	"github.com/Hashira21/currency-rate/internal/models/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+)
+
+type ratesDB struct {
+	Postgres
+
+	last         []models.CurrencyRateLast
+	prev         map[bool]models.CurrencyRateLast // предыдущий курс с ручными установками и без них
+	lastManual   []bool
+	prevManual   []bool
+	historical   []models.CurrencyRateWithDt
+	historyAudit models.AuditEntry
+}
+
+func (db *ratesDB) GetAllLastRates(ctx context.Context, excludeManual bool) ([]models.CurrencyRateLast, error) {
+	db.lastManual = append(db.lastManual, excludeManual)
+	return db.last, nil
+}
+
+func (db *ratesDB) GetPreviousRate(ctx context.Context, currency, base string, excludeManual bool) (models.CurrencyRateLast, error) {
+	db.prevManual = append(db.prevManual, excludeManual)
+	return db.prev[excludeManual], nil
+}
+
+func (db *ratesDB) GetRateAt(ctx context.Context, currency, base string, at time.Time) (models.CurrencyRateWithDt, error) {
+	return models.CurrencyRateWithDt{}, pgx.ErrNoRows
+}
+
+func (db *ratesDB) AddHistoricalRates(ctx context.Context, rates []models.CurrencyRateWithDt, audit models.AuditEntry) (int64, error) {
+	db.historical = append(db.historical, rates...)
+	db.historyAudit = audit
+	return int64(len(rates)), nil
+}
+
+type ratesProvider struct {
+	RateProvider
+}
+
+func (ratesProvider) GetRatesAt(ctx context.Context, fromIso string, toIsos []string, date time.Time) (models.ProviderRates, string, error) {
+	return models.ProviderRates{
+		Amount: decimal.NewFromInt(1),
+		Base:   fromIso,
+		Date:   date.Truncate(24 * time.Hour),
+		Rates:  map[string]decimal.Decimal{toIsos[0]: decimal.RequireFromString("0.9")},
+	}, "frankfurter", nil
+}
+
+func TestGetAllLastRatesExcludeManual(t *testing.T) {
+	tests := []struct {
+		name          string
+		excludeManual bool
+		wantChange    float64
+	}{
+		{name: "with manual rates", wantChange: 10},
+		{name: "without manual rates", excludeManual: true, wantChange: -50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &ratesDB{
+				last: []models.CurrencyRateLast{{Currency: "EUR", Base: "USD", Rate: decimal.NewFromInt(110)}},
+				prev: map[bool]models.CurrencyRateLast{
+					false: {Currency: "EUR", Base: "USD", Rate: decimal.NewFromInt(100), Source: models.RateSourceManual},
+					true:  {Currency: "EUR", Base: "USD", Rate: decimal.NewFromInt(220), Source: "frankfurter"},
+				},
+			}
+			svc := New(nil, db, config.CrossRates{}, config.AutoUpdate{}, config.SyncRates{}, zerolog.Nop())
+
+			rates, err := svc.GetAllLastRates(context.Background(), tt.excludeManual)
+			if err != nil {
+				t.Fatalf("GetAllLastRates() error = %v", err)
+			}
+
+			if db.lastManual[0] != tt.excludeManual || db.prevManual[0] != tt.excludeManual {
+				t.Errorf("excludeManual passed as last=%v previous=%v, want %v", db.lastManual, db.prevManual, tt.excludeManual)
+			}
+			if rates[0].ChangePct != tt.wantChange {
+				t.Errorf("ChangePct = %v, want %v", rates[0].ChangePct, tt.wantChange)
+			}
+		})
+	}
+}
+
+func TestGetRateAtCachesProviderRate(t *testing.T) {
+	db := &ratesDB{}
+	svc := New(ratesProvider{}, db, config.CrossRates{}, config.AutoUpdate{}, config.SyncRates{}, zerolog.Nop())
+
+	at := time.Now().AddDate(0, -1, 0)
+	rate, err := svc.GetRateAt(context.Background(), "EUR", "USD", at)
+	if err != nil {
+		t.Fatalf("GetRateAt() error = %v", err)
+	}
+	if rate.Source != RateSourceProvider {
+		t.Errorf("Source = %q, want %q", rate.Source, RateSourceProvider)
+	}
+
+	if len(db.historical) != 1 {
+		t.Fatalf("cached %d rates, want 1", len(db.historical))
+	}
+	if db.historical[0].Source != "frankfurter" {
+		t.Errorf("cached rate source = %q, want provider name", db.historical[0].Source)
+	}
+	// Курс сохраняется на запрошенный момент, чтобы покрыть этот день, с датой публикации провайдера
+	if !db.historical[0].UpdateDt.Equal(at) {
+		t.Errorf("cached rate date = %s, want requested %s", db.historical[0].UpdateDt, at)
+	}
+	if published := db.historical[0].PublishedDt; published == nil || !published.Equal(rate.UpdateDt) {
+		t.Errorf("cached rate published = %v, want provider date %s", published, rate.UpdateDt)
+	}
+	if db.historyAudit.Actor != auth.System.Subject {
+		t.Errorf("audit actor = %q, want %q", db.historyAudit.Actor, auth.System.Subject)
+	}
+
+	var summary map[string]any
+	if err = json.Unmarshal(db.historyAudit.NewValue, &summary); err != nil || summary["provider"] != "frankfurter" {
+		t.Errorf("audit entry %s = %s, want provider in new value", db.historyAudit.Action, db.historyAudit.NewValue)
+	}
+}
+
+func TestConvertSameCurrency(t *testing.T) {
+	svc := New(ratesProvider{}, &ratesDB{}, config.CrossRates{}, config.AutoUpdate{}, config.SyncRates{}, zerolog.Nop())
+
+	result, err := svc.Convert(context.Background(), "JPY", "JPY", decimal.RequireFromString("100.6"), "")
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+	if !result.Rate.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("Rate = %s, want 1", result.Rate)
+	}
+	// У иены нет дробной части, сумма округляется до целых
+	if !result.Result.Equal(decimal.NewFromInt(101)) {
+		t.Errorf("Result = %s, want 101", result.Result)
+	}
+}
@@ -6,7 +6,8 @@ host="db"
 shift
 cmd="$@"
 
-until PGPASSWORD=$DB_POSTGRES_PASSWORD psql -h "$host" -U "postgres" -c '\q'; do
+# DB_POSTGRES_* — прежние имена переменных, принимаются для совместимости
+until PGPASSWORD=${POSTGRES_PASSWORD:-$DB_POSTGRES_PASSWORD} psql -h "$host" -U "${POSTGRES_USER:-${DB_POSTGRES_USER:-postgres}}" -c '\q'; do
   >&2 echo "Postgres is unavailable - sleeping"
   sleep 1
 done